@@ -0,0 +1,229 @@
+package client
+
+import (
+    "crypto/subtle"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "net/http"
+    "strings"
+)
+
+// AdminOptions 管理端http处理器配置
+type AdminOptions struct {
+    // 路径前缀(如: /eureka-admin), 可选
+    PathPrefix string
+    // BasicAuth用户名, 用户名及密码均为空时不校验
+    Username string
+    // BasicAuth密码, 用户名及密码均为空时不校验
+    Password string
+    // 是否只读(禁止拉取服务列表、变更状态/元数据及重新注册)
+    ReadOnly bool
+}
+
+// AdminHandler 管理端http处理器, 用于查看及调整eureka客户端:
+//
+//	GET      {prefix}/apps[?zone=]    查询各zone服务发现缓存
+//	GET      {prefix}/apps/{appName}  查询各zone指定服务缓存
+//	GET      {prefix}/instance        查询当前服务实例信息
+//	POST     {prefix}/fetch           立即拉取服务列表
+//	PUT/POST {prefix}/status?value=   变更服务状态
+//	PUT/POST {prefix}/metadata        变更元数据(请求体为JSON对象)
+//	POST     {prefix}/register        重新注册服务实例
+type AdminHandler struct {
+    client  *EurekaClient
+    options *AdminOptions
+}
+
+// AdminHandler 创建管理端http处理器, options 为空时使用默认配置
+func (client *EurekaClient) AdminHandler(options *AdminOptions) *AdminHandler {
+    if options == nil {
+        options = &AdminOptions{}
+    }
+    return &AdminHandler{client: client, options: options}
+}
+
+// ServeHTTP 处理管理端请求
+func (handler *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            handler.client.GetLogger().Errorf("AdminHandler.ServeHTTP, recover error: %v", rc)
+            writeAdminError(w, http.StatusInternalServerError, errors.New(fmt.Sprintf("recover error: %v", rc)))
+        }
+    }()
+    if !handler.authorized(r) {
+        w.Header().Set("WWW-Authenticate", `Basic realm="eureka-client-admin"`)
+        writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+        return
+    }
+    prefix := strings.TrimRight(handler.options.PathPrefix, "/")
+    if r.URL.Path != prefix && !strings.HasPrefix(r.URL.Path, prefix+"/") {
+        writeAdminError(w, http.StatusNotFound, errors.New("not found: "+r.URL.Path))
+        return
+    }
+    path := "/" + strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+    switch {
+    case path == "/apps":
+        handler.handleApps(w, r, "")
+    case strings.HasPrefix(path, "/apps/"):
+        handler.handleApps(w, r, strings.TrimPrefix(path, "/apps/"))
+    case path == "/instance":
+        handler.handleInstance(w, r)
+    case path == "/fetch":
+        handler.handleFetch(w, r)
+    case path == "/status":
+        handler.handleStatus(w, r)
+    case path == "/metadata":
+        handler.handleMetadata(w, r)
+    case path == "/register":
+        handler.handleRegister(w, r)
+    default:
+        writeAdminError(w, http.StatusNotFound, errors.New("not found: "+r.URL.Path))
+    }
+}
+
+// authorized 校验BasicAuth
+func (handler *AdminHandler) authorized(r *http.Request) bool {
+    if handler.options.Username == "" && handler.options.Password == "" {
+        return true
+    }
+    username, password, ok := r.BasicAuth()
+    if !ok {
+        return false
+    }
+    usernameMatched := subtle.ConstantTimeCompare([]byte(username), []byte(handler.options.Username)) == 1
+    passwordMatched := subtle.ConstantTimeCompare([]byte(password), []byte(handler.options.Password)) == 1
+    return usernameMatched && passwordMatched
+}
+
+// allow 校验请求方法, 变更类请求在只读模式下禁止
+func (handler *AdminHandler) allow(w http.ResponseWriter, r *http.Request, modify bool, methods ...string) bool {
+    for _, method := range methods {
+        if r.Method == method {
+            if modify && handler.options.ReadOnly {
+                writeAdminError(w, http.StatusForbidden, errors.New("admin handler is read-only"))
+                return false
+            }
+            return true
+        }
+    }
+    w.Header().Set("Allow", strings.Join(methods, ", "))
+    writeAdminError(w, http.StatusMethodNotAllowed, errors.New("method not allowed: "+r.Method))
+    return false
+}
+
+// handleApps 查询服务发现缓存
+func (handler *AdminHandler) handleApps(w http.ResponseWriter, r *http.Request, appName string) {
+    if !handler.allow(w, r, false, http.MethodGet) {
+        return
+    }
+    zone := r.URL.Query().Get("zone")
+    ret := make(map[string][]*meta.AppInfo)
+    for z, apps := range handler.client.discoveryClient.Apps {
+        if zone != "" && zone != z {
+            continue
+        }
+        ret[z] = make([]*meta.AppInfo, 0)
+        for _, app := range apps {
+            if appName == "" || strings.EqualFold(app.Name, appName) {
+                ret[z] = append(ret[z], app)
+            }
+        }
+    }
+    writeAdminJson(w, http.StatusOK, ret)
+}
+
+// handleInstance 查询当前服务实例信息
+func (handler *AdminHandler) handleInstance(w http.ResponseWriter, r *http.Request) {
+    if !handler.allow(w, r, false, http.MethodGet) {
+        return
+    }
+    registry := handler.client.registryClient
+    status := registry.status
+    if status == "" {
+        status = meta.StatusStarting
+        if *registry.Config.InstanceEnabledOnIt {
+            status = meta.StatusUp
+        }
+    }
+    instance, err := registry.buildInstanceInfo(status, meta.Added)
+    if err != nil {
+        writeAdminError(w, http.StatusInternalServerError, err)
+        return
+    }
+    writeAdminJson(w, http.StatusOK, instance)
+}
+
+// handleFetch 立即拉取服务列表
+func (handler *AdminHandler) handleFetch(w http.ResponseWriter, r *http.Request) {
+    if !handler.allow(w, r, true, http.MethodPost) {
+        return
+    }
+    apps, err := handler.client.FetchApps()
+    if err != nil {
+        writeAdminError(w, http.StatusServiceUnavailable, err)
+        return
+    }
+    writeAdminJson(w, http.StatusOK, SummaryAppsMap(apps))
+}
+
+// handleStatus 变更服务状态
+func (handler *AdminHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+    if !handler.allow(w, r, true, http.MethodPut, http.MethodPost) {
+        return
+    }
+    status := meta.InstanceStatus(strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("value"))))
+    if status == "" {
+        writeAdminError(w, http.StatusBadRequest, errors.New("query parameter 'value' is required"))
+        return
+    }
+    handler.writeCommonResponse(w, handler.client.ChangeStatus(status))
+}
+
+// handleMetadata 变更元数据
+func (handler *AdminHandler) handleMetadata(w http.ResponseWriter, r *http.Request) {
+    if !handler.allow(w, r, true, http.MethodPut, http.MethodPost) {
+        return
+    }
+    metadata := make(map[string]string)
+    if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+        writeAdminError(w, http.StatusBadRequest, errors.New(fmt.Sprintf("failed to parse metadata: %v", err)))
+        return
+    }
+    handler.writeCommonResponse(w, handler.client.ChangeMetadata(metadata))
+}
+
+// handleRegister 重新注册服务实例
+func (handler *AdminHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
+    if !handler.allow(w, r, true, http.MethodPost) {
+        return
+    }
+    handler.writeCommonResponse(w, handler.client.ReRegister())
+}
+
+// writeCommonResponse 输出 *CommonResponse 处理结果
+func (handler *AdminHandler) writeCommonResponse(w http.ResponseWriter, response *CommonResponse) {
+    if response.Error != nil {
+        writeAdminError(w, http.StatusServiceUnavailable, response.Error)
+        return
+    }
+    writeAdminJson(w, http.StatusOK, map[string]interface{}{"success": true, "statusCode": response.StatusCode})
+}
+
+// writeAdminError 输出JSON格式错误信息
+func writeAdminError(w http.ResponseWriter, code int, err error) {
+    writeAdminJson(w, code, map[string]interface{}{"success": false, "error": err.Error()})
+}
+
+// writeAdminJson 输出JSON格式响应
+func writeAdminJson(w http.ResponseWriter, code int, body interface{}) {
+    data, err := json.Marshal(body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    _, _ = w.Write(data)
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+    "encoding/json"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+// doAdminRequest 调用管理端处理器并解析JSON响应
+func doAdminRequest(ast *assert.Assertions, handler http.Handler, method, target string, body io.Reader, ret interface{}) int {
+    recorder := httptest.NewRecorder()
+    request := httptest.NewRequest(method, target, body)
+    request.SetBasicAuth("admin", "secret")
+    handler.ServeHTTP(recorder, request)
+    ast.Equal("application/json", recorder.Header().Get("Content-Type"))
+    if ret != nil {
+        ast.Nil(json.Unmarshal(recorder.Body.Bytes(), ret))
+    }
+    return recorder.Code
+}
+
+func TestAdminHandler(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:       "admin-test",
+            InstanceId:    "127.0.0.1:28088",
+            NonSecurePort: 28088,
+            Metadata:      map[string]string{"version": "v1"},
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    defer client.Stop()
+    handler := client.AdminHandler(&AdminOptions{PathPrefix: "/admin/", Username: "admin", Password: "secret"})
+
+    // BasicAuth
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/apps", nil))
+    ast.Equal(http.StatusUnauthorized, recorder.Code)
+    ast.NotEmpty(recorder.Header().Get("WWW-Authenticate"))
+    // 仅配置密码时同样校验
+    passwordOnly := client.AdminHandler(&AdminOptions{Password: "secret"})
+    recorder = httptest.NewRecorder()
+    passwordOnly.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/instance", nil))
+    ast.Equal(http.StatusUnauthorized, recorder.Code)
+
+    // 当前服务实例信息
+    instance := &meta.InstanceInfo{}
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodGet, "/admin/instance", nil, instance))
+    ast.Equal("127.0.0.1:28088", instance.InstanceId)
+    ast.Equal(meta.StatusUp, instance.Status)
+    ast.Equal("v1", instance.Metadata["version"])
+
+    // 拉取服务列表并查询缓存
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodPost, "/admin/fetch", nil, nil))
+    apps := make(map[string][]*meta.AppInfo)
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodGet, "/admin/apps", nil, &apps))
+    ast.Len(apps[meta.DefaultZone], 1)
+    ast.Equal("ADMIN-TEST", apps[meta.DefaultZone][0].Name)
+    apps = make(map[string][]*meta.AppInfo)
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodGet, "/admin/apps/admin-test?zone=missing", nil, &apps))
+    ast.Len(apps, 0)
+    apps = make(map[string][]*meta.AppInfo)
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodGet, "/admin/apps/other", nil, &apps))
+    ast.Len(apps[meta.DefaultZone], 0)
+
+    // 变更服务状态
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodPut, "/admin/status?value=OUT_OF_SERVICE", nil, nil))
+    ast.Equal(meta.StatusOutOfService, server.Instance("admin-test", "127.0.0.1:28088").Status)
+    ast.Equal(http.StatusBadRequest, doAdminRequest(ast, handler, http.MethodPut, "/admin/status", nil, nil))
+    ast.Equal(http.StatusMethodNotAllowed, doAdminRequest(ast, handler, http.MethodGet, "/admin/status?value=UP", nil, nil))
+
+    // 变更元数据
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodPut, "/admin/metadata", strings.NewReader(`{"version":"v2"}`), nil))
+    ast.Equal("v2", client.registryClient.Config.Metadata["version"])
+    ast.Equal("v2", server.Instance("admin-test", "127.0.0.1:28088").Metadata["version"])
+    ast.Equal(http.StatusBadRequest, doAdminRequest(ast, handler, http.MethodPut, "/admin/metadata", strings.NewReader(`[]`), nil))
+
+    // 重新注册(服务端元数据与本地一致)
+    ast.Equal(http.StatusOK, doAdminRequest(ast, handler, http.MethodPost, "/admin/register", nil, nil))
+    registered := server.Instance("admin-test", "127.0.0.1:28088")
+    ast.Equal(meta.StatusOutOfService, registered.Status)
+    ast.Equal("v2", registered.Metadata["version"])
+
+    ast.Equal(http.StatusNotFound, doAdminRequest(ast, handler, http.MethodGet, "/admin/unknown", nil, nil))
+    // 路径前缀不匹配
+    ast.Equal(http.StatusNotFound, doAdminRequest(ast, handler, http.MethodGet, "/apps", nil, nil))
+    ast.Equal(http.StatusNotFound, doAdminRequest(ast, handler, http.MethodGet, "/administrator/apps", nil, nil))
+
+    // 只读
+    readOnly := client.AdminHandler(&AdminOptions{ReadOnly: true})
+    ast.Equal(http.StatusForbidden, doAdminRequest(ast, readOnly, http.MethodPost, "/register", nil, nil))
+    ast.Equal(http.StatusOK, doAdminRequest(ast, readOnly, http.MethodGet, "/instance", nil, nil))
+}
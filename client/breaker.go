@@ -0,0 +1,253 @@
+package client
+
+import (
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/log"
+    "sort"
+    "sync"
+    "time"
+)
+
+var (
+    // DefaultBreakerFailureThreshold 默认触发熔断的连续失败次数
+    DefaultBreakerFailureThreshold = 5
+    // DefaultBreakerSuccessThreshold 默认半开状态下恢复闭合的连续成功次数
+    DefaultBreakerSuccessThreshold = 1
+    // DefaultBreakerCoolDown 默认熔断冷却时长(熔断后经过该时长进入半开状态)
+    DefaultBreakerCoolDown         = 30 * time.Second
+)
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+const (
+    BreakerClosed   BreakerState = "CLOSED"
+    BreakerOpen     BreakerState = "OPEN"
+    BreakerHalfOpen BreakerState = "HALF_OPEN"
+)
+
+// CircuitBreakerConfig eureka server服务地址熔断配置
+type CircuitBreakerConfig struct {
+    // 连续失败次数达到该值时熔断(CLOSED -> OPEN), 默认: DefaultBreakerFailureThreshold
+    FailureThreshold int
+    // 半开状态下连续成功次数达到该值时恢复(HALF_OPEN -> CLOSED), 默认: DefaultBreakerSuccessThreshold
+    SuccessThreshold int
+    // 熔断冷却时长, 到期后允许试探请求(OPEN -> HALF_OPEN), 默认: DefaultBreakerCoolDown
+    CoolDown time.Duration
+}
+
+// BreakerEvent 熔断器状态变更事件
+type BreakerEvent struct {
+    // eureka server服务地址
+    Endpoint string
+    From     BreakerState
+    To       BreakerState
+    // 状态变更原因
+    Reason string
+    Time   time.Time
+}
+
+// endpointBreaker 单个eureka server服务地址熔断状态
+type endpointBreaker struct {
+    state     BreakerState
+    failures  int
+    successes int
+    openedAt  time.Time
+    probing   bool
+}
+
+// CircuitBreaker eureka server服务地址熔断器(按服务地址独立统计), 熔断期间 HttpClient 直接尝试下一个服务地址
+type CircuitBreaker struct {
+    Logger    log.Logger
+    config    *CircuitBreakerConfig
+    endpoints map[string]*endpointBreaker
+    mutex     sync.Mutex
+    listeners listenerRegistry[func(event *BreakerEvent)]
+    now       func() time.Time
+}
+
+// NewCircuitBreaker 根据 *CircuitBreakerConfig 创建熔断器
+func NewCircuitBreaker(config *CircuitBreakerConfig) *CircuitBreaker {
+    nc := &CircuitBreakerConfig{}
+    if config != nil {
+        *nc = *config
+    }
+    if nc.FailureThreshold <= 0 {
+        nc.FailureThreshold = DefaultBreakerFailureThreshold
+    }
+    if nc.SuccessThreshold <= 0 {
+        nc.SuccessThreshold = DefaultBreakerSuccessThreshold
+    }
+    if nc.CoolDown <= 0 {
+        nc.CoolDown = DefaultBreakerCoolDown
+    }
+    return &CircuitBreaker{
+        config:    nc,
+        endpoints: make(map[string]*endpointBreaker),
+        now:       time.Now,
+    }
+}
+
+// GetLogger 获取日志对象
+func (breaker *CircuitBreaker) GetLogger() log.Logger {
+    if breaker.Logger == nil {
+        breaker.Logger = log.DefaultLoggerImpl
+    }
+    return breaker.Logger
+}
+
+// State 查询指定eureka server服务地址熔断状态(冷却到期的OPEN状态返回HALF_OPEN)
+func (breaker *CircuitBreaker) State(endpoint string) BreakerState {
+    breaker.mutex.Lock()
+    defer breaker.mutex.Unlock()
+    if eb, ok := breaker.endpoints[endpoint]; ok {
+        return breaker.currentState(eb)
+    }
+    return BreakerClosed
+}
+
+// States 查询所有已记录eureka server服务地址的熔断状态
+func (breaker *CircuitBreaker) States() map[string]BreakerState {
+    breaker.mutex.Lock()
+    defer breaker.mutex.Unlock()
+    states := make(map[string]BreakerState, len(breaker.endpoints))
+    for endpoint, eb := range breaker.endpoints {
+        states[endpoint] = breaker.currentState(eb)
+    }
+    return states
+}
+
+// OpenEndpoints 查询当前处于熔断状态的eureka server服务地址列表
+func (breaker *CircuitBreaker) OpenEndpoints() []string {
+    endpoints := make([]string, 0)
+    for endpoint, state := range breaker.States() {
+        if state == BreakerOpen {
+            endpoints = append(endpoints, endpoint)
+        }
+    }
+    sort.Strings(endpoints)
+    return endpoints
+}
+
+// Reset 重置指定eureka server服务地址熔断状态为CLOSED
+func (breaker *CircuitBreaker) Reset(endpoint string) {
+    breaker.mutex.Lock()
+    eb, ok := breaker.endpoints[endpoint]
+    var event *BreakerEvent
+    if ok {
+        event = breaker.transition(endpoint, eb, BreakerClosed, "reset")
+    }
+    breaker.mutex.Unlock()
+    breaker.publish(event)
+}
+
+// Subscribe 订阅熔断器状态变更事件, 返回取消订阅函数
+func (breaker *CircuitBreaker) Subscribe(listener func(event *BreakerEvent)) (unsubscribe func()) {
+    return breaker.listeners.add(listener)
+}
+
+// allow 是否允许向指定eureka server服务地址发送请求(半开状态下同时仅允许一个试探请求)
+func (breaker *CircuitBreaker) allow(endpoint string) bool {
+    breaker.mutex.Lock()
+    eb, ok := breaker.endpoints[endpoint]
+    if !ok {
+        breaker.mutex.Unlock()
+        return true
+    }
+    var event *BreakerEvent
+    allowed := true
+    switch eb.state {
+    case BreakerOpen:
+        if breaker.currentState(eb) == BreakerOpen {
+            allowed = false
+            break
+        }
+        event = breaker.transition(endpoint, eb, BreakerHalfOpen, "cool-down elapsed")
+        eb.probing = true
+    case BreakerHalfOpen:
+        if eb.probing {
+            allowed = false
+            break
+        }
+        eb.probing = true
+    }
+    breaker.mutex.Unlock()
+    breaker.publish(event)
+    return allowed
+}
+
+// report 记录指定eureka server服务地址通讯结果, 达到阈值时变更熔断状态
+func (breaker *CircuitBreaker) report(endpoint string, err error) {
+    breaker.mutex.Lock()
+    eb, ok := breaker.endpoints[endpoint]
+    if !ok {
+        eb = &endpointBreaker{state: BreakerClosed}
+        breaker.endpoints[endpoint] = eb
+    }
+    var event *BreakerEvent
+    config := breaker.config
+    switch eb.state {
+    case BreakerClosed:
+        if err == nil {
+            eb.failures = 0
+            break
+        }
+        eb.failures++
+        if eb.failures >= config.FailureThreshold {
+            event = breaker.transition(endpoint, eb, BreakerOpen, fmt.Sprintf("consecutive failures: %d, error: %v", eb.failures, err))
+        }
+    case BreakerHalfOpen:
+        eb.probing = false
+        if err != nil {
+            event = breaker.transition(endpoint, eb, BreakerOpen, fmt.Sprintf("probe failed, error: %v", err))
+            break
+        }
+        eb.successes++
+        if eb.successes >= config.SuccessThreshold {
+            event = breaker.transition(endpoint, eb, BreakerClosed, fmt.Sprintf("consecutive successes: %d", eb.successes))
+        }
+    }
+    breaker.mutex.Unlock()
+    breaker.publish(event)
+}
+
+// release 释放试探请求(请求被取消, 不计入统计)
+func (breaker *CircuitBreaker) release(endpoint string) {
+    breaker.mutex.Lock()
+    defer breaker.mutex.Unlock()
+    if eb, ok := breaker.endpoints[endpoint]; ok {
+        eb.probing = false
+    }
+}
+
+// currentState 获取当前熔断状态
+func (breaker *CircuitBreaker) currentState(eb *endpointBreaker) BreakerState {
+    if eb.state == BreakerOpen && breaker.now().Sub(eb.openedAt) >= breaker.config.CoolDown {
+        return BreakerHalfOpen
+    }
+    return eb.state
+}
+
+// transition 变更熔断状态并重置统计
+func (breaker *CircuitBreaker) transition(endpoint string, eb *endpointBreaker, to BreakerState, reason string) *BreakerEvent {
+    from, now := eb.state, breaker.now()
+    eb.state, eb.failures, eb.successes, eb.probing = to, 0, 0, false
+    if to == BreakerOpen {
+        eb.openedAt = now
+    }
+    if from == to {
+        return nil
+    }
+    return &BreakerEvent{Endpoint: endpoint, From: from, To: to, Reason: reason, Time: now}
+}
+
+// publish 发布熔断器状态变更事件
+func (breaker *CircuitBreaker) publish(event *BreakerEvent) {
+    if event == nil {
+        return
+    }
+    breaker.GetLogger().Warnf("CircuitBreaker.publish, %s -> %s >>> endpoint: %s, reason: %s", event.From, event.To, event.Endpoint, event.Reason)
+    breaker.listeners.notify(breaker.GetLogger(), "CircuitBreaker.publish", func(listener func(event *BreakerEvent)) {
+        listener(event)
+    })
+}
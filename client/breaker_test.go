@@ -0,0 +1,155 @@
+package client
+
+import (
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+    ast := assert.New(t)
+    current := time.Now()
+    breaker := NewCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, SuccessThreshold: 2, CoolDown: time.Minute})
+    breaker.now = func() time.Time { return current }
+    events := make([]*BreakerEvent, 0)
+    unsubscribe := breaker.Subscribe(func(event *BreakerEvent) {
+        events = append(events, event)
+    })
+    failure := errors.New("connection refused")
+
+    ast.Equal(BreakerClosed, breaker.State("http://a"))
+    ast.True(breaker.allow("http://a"))
+    breaker.report("http://a", failure)
+    breaker.report("http://a", nil)
+    breaker.report("http://a", failure)
+    ast.Equal(BreakerClosed, breaker.State("http://a"))
+    breaker.report("http://a", failure)
+    ast.Equal(BreakerOpen, breaker.State("http://a"))
+    ast.False(breaker.allow("http://a"))
+    ast.Equal([]string{"http://a"}, breaker.OpenEndpoints())
+    ast.Len(events, 1)
+    ast.Equal(BreakerClosed, events[0].From)
+    ast.Equal(BreakerOpen, events[0].To)
+    ast.Contains(events[0].Reason, "connection refused")
+
+    // 冷却到期后仅允许一个试探请求, 试探失败重新熔断
+    current = current.Add(time.Minute)
+    ast.Equal(BreakerHalfOpen, breaker.State("http://a"))
+    ast.True(breaker.allow("http://a"))
+    ast.False(breaker.allow("http://a"))
+    breaker.report("http://a", failure)
+    ast.Equal(BreakerOpen, breaker.State("http://a"))
+    ast.Len(events, 3)
+    ast.Equal(BreakerHalfOpen, events[1].To)
+    ast.Equal(BreakerOpen, events[2].To)
+
+    // 半开状态下连续成功达到阈值后恢复
+    current = current.Add(time.Minute)
+    ast.True(breaker.allow("http://a"))
+    breaker.report("http://a", nil)
+    ast.Equal(BreakerHalfOpen, breaker.State("http://a"))
+    ast.True(breaker.allow("http://a"))
+    breaker.report("http://a", nil)
+    ast.Equal(BreakerClosed, breaker.State("http://a"))
+    ast.Len(events, 5)
+    ast.Equal(BreakerClosed, events[4].To)
+
+    // 重置
+    breaker.report("http://b", failure)
+    breaker.report("http://b", failure)
+    ast.Equal(map[string]BreakerState{"http://a": BreakerClosed, "http://b": BreakerOpen}, breaker.States())
+    breaker.Reset("http://b")
+    ast.Equal(BreakerClosed, breaker.State("http://b"))
+    ast.Len(events, 7)
+
+    unsubscribe()
+    breaker.report("http://b", failure)
+    breaker.report("http://b", failure)
+    ast.Len(events, 7)
+}
+
+func TestHttpClient_CircuitBreaker(t *testing.T) {
+    ast := assert.New(t)
+    var healthy, failingCount int32
+    failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&failingCount, 1)
+        if atomic.LoadInt32(&healthy) == 0 {
+            w.WriteHeader(http.StatusServiceUnavailable)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer failing.Close()
+    backup, backupCount := newTestRetryServer(0, http.StatusOK)
+    defer backup.Close()
+
+    current := time.Now()
+    breaker := NewCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute})
+    breaker.now = func() time.Time { return current }
+    var mutex sync.Mutex
+    states := make([]BreakerState, 0)
+    breaker.Subscribe(func(event *BreakerEvent) {
+        mutex.Lock()
+        defer mutex.Unlock()
+        ast.Equal(failing.URL, event.Endpoint)
+        states = append(states, event.To)
+    })
+    client := &HttpClient{CircuitBreaker: breaker}
+    serviceUrl := failing.URL + "," + backup.URL
+
+    for i := 0; i < 2; i++ {
+        response := client.SimpleHeartbeat(serviceUrl, "APP", "instance-1")
+        ast.Nilf(response.Error, "%v", response.Error)
+    }
+    ast.Equal(int32(2), atomic.LoadInt32(&failingCount))
+    ast.Equal(BreakerOpen, breaker.State(failing.URL))
+    ast.Equal(BreakerClosed, breaker.State(backup.URL))
+
+    // 熔断期间直接尝试下一个服务地址
+    response := client.SimpleHeartbeat(serviceUrl, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(2), atomic.LoadInt32(&failingCount))
+    ast.Equal(int32(3), atomic.LoadInt32(backupCount))
+    ast.Len(response.Response.Responses, 2)
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrCircuitOpen))
+    ast.Nil(response.Response.Responses[0].HttpResponse)
+
+    // 所有服务地址均熔断时快速失败
+    response = client.SimpleHeartbeat(failing.URL, "APP", "instance-1")
+    ast.True(errors.Is(response.Error, ErrCircuitOpen))
+    ast.Equal(int32(2), atomic.LoadInt32(&failingCount))
+
+    // 404等非服务端故障不计入失败
+    notFound, _ := newTestRetryServer(100, http.StatusNotFound)
+    defer notFound.Close()
+    for i := 0; i < 3; i++ {
+        client.SimpleHeartbeat(notFound.URL, "APP", "instance-1")
+    }
+    ast.Equal(BreakerClosed, breaker.State(notFound.URL))
+
+    // 冷却到期后试探请求成功, 恢复
+    atomic.StoreInt32(&healthy, 1)
+    current = current.Add(time.Minute)
+    response = client.SimpleHeartbeat(serviceUrl, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(failing.URL, response.Response.Request.ServiceUrl)
+    ast.Equal(BreakerClosed, breaker.State(failing.URL))
+    mutex.Lock()
+    ast.Equal([]BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}, states)
+    mutex.Unlock()
+
+    // 与重试策略组合: 熔断后不再重试
+    atomic.StoreInt32(&healthy, 0)
+    atomic.StoreInt32(&failingCount, 0)
+    client.RetryPolicy = &RetryPolicy{MaxAttempts: 5, InitialBackoff: -1}
+    response = client.SimpleHeartbeat(serviceUrl, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(2), atomic.LoadInt32(&failingCount))
+    ast.Len(response.Response.Responses, 4)
+    ast.True(errors.Is(response.Response.Responses[2].Error, ErrCircuitOpen))
+}
@@ -9,6 +9,8 @@ import (
     "github.com/jiashunx/eureka-client-go/meta"
     "strconv"
     "strings"
+    "sync"
+    "time"
 )
 
 // eurekaClientUUID context中存储的客户端uuid属性名称
@@ -16,12 +18,12 @@ var eurekaClientUUID = "EurekaClientUUID"
 
 // clientNotStartedErr 错误:客户端未启动
 var clientNotStartedErr = func() error {
-    return errors.New("eureka client has not been started")
+    return ErrClientNotStarted
 }
 
 // clientHasBeenStoppedErr 错误:客户端已关闭
 var clientHasBeenStoppedErr = func() error {
-    return errors.New("eureka client has already been stopped")
+    return ErrClientStopped
 }
 
 // EurekaClient eureka客户端模型
@@ -34,7 +36,13 @@ type EurekaClient struct {
     httpClient      *HttpClient
     registryClient  *RegistryClient
     discoveryClient *DiscoveryClient
+    router          *Router
+    registrations   *RegistrationManager
     logger          log.Logger
+    // 生命周期状态
+    state          ClientState
+    stateListeners listenerRegistry[StateListener]
+    stateMutex     sync.Mutex
 }
 
 // Start 启动eureka客户端
@@ -44,10 +52,15 @@ func (client *EurekaClient) Start() *CommonResponse {
 
 // StartWithCtx 启动eureka客户端并指定 context.Context
 func (client *EurekaClient) StartWithCtx(ctx context.Context) (response *CommonResponse) {
+    // 是否已变更为启动中状态
+    starting := false
     defer func() {
         if rc := recover(); rc != nil {
             response = &CommonResponse{}
             response.Error = errors.New(fmt.Sprintf("EurekaClient.StartWithCtx, recover error: %v", rc))
+            if starting {
+                client.abortStart()
+            }
         }
         if response.Error != nil {
             client.GetLogger().Errorf("EurekaClient.StartWithCtx, FAILED >>> error: %v", response.Error)
@@ -65,39 +78,65 @@ func (client *EurekaClient) StartWithCtx(ctx context.Context) (response *CommonR
     if ctx == nil {
         ctx = client.rootCtx
     }
-    if client.ctx != nil {
-        select {
-        case <-client.ctx.Done():
-            break
-        default:
-            return &CommonResponse{Error: errors.New("eureka client is still running")}
-        }
+    if state, err := client.transition(StateStarting, StateCreated, StateStopped); err != nil {
+        return &CommonResponse{Error: errors.New(fmt.Sprintf("eureka client is still running, state: %s", state))}
     }
+    starting = true
+    client.stateMutex.Lock()
     client.ctx, client.ctxCancel = context.WithCancel(ctx)
-    subCtx := context.WithValue(client.ctx, eurekaClientUUID, client.UUID)
+    clientCtx := client.ctx
+    client.stateMutex.Unlock()
+    go client.watchContext(clientCtx)
+    subCtx := context.WithValue(clientCtx, eurekaClientUUID, client.UUID)
     if response = client.registryClient.start(subCtx); response.Error != nil {
         client.GetLogger().Errorf("EurekaClient.StartWithCtx, failed to start registry client, try to stop eureka client")
-        client.Stop()
-        client.ctxCancel()
+        client.abortStart()
         return response
     }
     if response = client.discoveryClient.start(subCtx); response.Error != nil {
         client.GetLogger().Errorf("EurekaClient.StartWithCtx, failed to start discovery client, try to stop eureka client")
-        client.Stop()
-        client.ctxCancel()
+        client.abortStart()
         return response
     }
+    client.registrations.start(subCtx)
+    _, _ = client.transition(StateRegistered, StateStarting)
     return &CommonResponse{Error: nil}
 }
 
+// abortStart 启动失败时停止后台任务(服务实例未注册, 无需取消注册)
+func (client *EurekaClient) abortStart() {
+    client.stateMutex.Lock()
+    ctxCancel := client.ctxCancel
+    client.stateMutex.Unlock()
+    if ctxCancel != nil {
+        ctxCancel()
+    }
+    client.registryClient.wait()
+    client.discoveryClient.wait()
+    client.registrations.wait()
+    _, _ = client.transition(StateStopped, StateStarting)
+}
+
 // Stop 关闭eureka客户端（方法执行成功后才关闭）
 func (client *EurekaClient) Stop() *CommonResponse {
     ret, err := client.exec("Stop", func(params ...any) (any, error) {
-        response := client.registryClient.UnRegister()
+        prev, err := client.transition(StateStopping, StateRegistered, StateRegistrationFailing)
+        if err != nil {
+            return nil, err
+        }
+        response := &CommonResponse{}
+        if registryEnabled, _ := client.registryClient.isEnabled(); registryEnabled {
+            response = client.registryClient.UnRegister()
+        }
         if response.Error == nil {
+            if err := client.registrations.unRegisterAll(); err != nil {
+                client.GetLogger().Errorf("EurekaClient.Stop, %v", err)
+            }
             client.ctxCancel()
+            _, _ = client.transition(StateStopped)
             return response, nil
         }
+        _, _ = client.transition(prev, StateStopping)
         return nil, response.Error
     })
     if err != nil {
@@ -106,20 +145,109 @@ func (client *EurekaClient) Stop() *CommonResponse {
     return ret.(*CommonResponse)
 }
 
+// GracefulStop 优雅关闭eureka客户端: 变更服务状态为 meta.StatusOutOfService, 等待流量排空后取消注册,
+// 停止后台任务并等待所有后台goroutine退出, ctx 用于限制整个关闭过程的最长时间(超时后跳过剩余等待)
+func (client *EurekaClient) GracefulStop(ctx context.Context, options *GracefulStopOptions) *CommonResponse {
+    if ctx == nil {
+        ctx = context.Background()
+    }
+    if options == nil {
+        options = &GracefulStopOptions{}
+    }
+    ret, err := client.exec("GracefulStop", func(params ...any) (any, error) {
+        return client.gracefulStop(params[0].(context.Context), params[1].(*GracefulStopOptions)), nil
+    }, ctx, options)
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    return ret.(*CommonResponse)
+}
+
+// gracefulStop 优雅关闭处理
+func (client *EurekaClient) gracefulStop(ctx context.Context, options *GracefulStopOptions) (response *CommonResponse) {
+    if _, err := client.transition(StateStopping, StateRegistered, StateRegistrationFailing); err != nil {
+        return &CommonResponse{Error: err}
+    }
+    defer func() {
+        _, _ = client.transition(StateStopped)
+    }()
+    registryEnabled, _ := client.registryClient.isEnabled()
+    if registryEnabled {
+        if response = client.registryClient.ChangeStatus(meta.StatusOutOfService); response.Error != nil {
+            client.GetLogger().Warnf("EurekaClient.GracefulStop, failed to change status to %s, error: %v", meta.StatusOutOfService, response.Error)
+        }
+    }
+    client.registrations.changeAllStatus(meta.StatusOutOfService)
+    drainPeriod := options.DrainPeriod
+    if drainPeriod == 0 {
+        drainPeriod = time.Duration(client.config.RegistryFetchIntervalSeconds) * time.Second
+    }
+    if drainPeriod > 0 {
+        client.GetLogger().Tracef("EurekaClient.GracefulStop, waiting for drain period: %v", drainPeriod)
+        timer := time.NewTimer(drainPeriod)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+        case <-timer.C:
+        }
+    }
+    if options.Drained != nil {
+        interval := options.DrainCheckInterval
+        if interval <= 0 {
+            interval = DefaultDrainCheckInterval
+        }
+        ticker := time.NewTicker(interval)
+    FL:
+        for !options.Drained() {
+            select {
+            case <-ctx.Done():
+                client.GetLogger().Warnf("EurekaClient.GracefulStop, stop waiting for drained: %v", ctx.Err())
+                break FL
+            case <-ticker.C:
+            }
+        }
+        ticker.Stop()
+    }
+    response = &CommonResponse{}
+    if registryEnabled {
+        response = client.registryClient.UnRegister()
+    }
+    if err := client.registrations.unRegisterAll(); err != nil {
+        client.GetLogger().Errorf("EurekaClient.GracefulStop, %v", err)
+    }
+    client.ctxCancel()
+    done := make(chan struct{})
+    go func() {
+        client.registryClient.wait()
+        client.discoveryClient.wait()
+        client.registrations.wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        if response.Error == nil {
+            response.Error = errors.New(fmt.Sprintf("timed out waiting for background goroutines to exit: %v", ctx.Err()))
+        }
+    }
+    return response
+}
+
 // ForceStop 强行关闭eureka客户端
 func (client *EurekaClient) ForceStop() {
-    if client.ctx != nil {
-        select {
-        case <-client.ctx.Done():
-            break
-        default:
-            defer client.ctxCancel()
-            client.GetLogger().Tracef("EurekaClient.ForceStop, try to stop eureka client")
+    if _, err := client.transition(StateStopping, StateRegistered, StateRegistrationFailing); err == nil {
+        client.GetLogger().Tracef("EurekaClient.ForceStop, try to stop eureka client")
+        if registryEnabled, _ := client.registryClient.isEnabled(); registryEnabled {
             response := client.registryClient.UnRegister()
             if response.Error != nil {
                 client.GetLogger().Tracef("EurekaClient.ForceStop, failed to unRegister, error: %v", response.Error)
             }
         }
+        if err := client.registrations.unRegisterAll(); err != nil {
+            client.GetLogger().Tracef("EurekaClient.ForceStop, %v", err)
+        }
+        client.ctxCancel()
+        _, _ = client.transition(StateStopped)
     }
     client.GetLogger().Tracef("EurekaClient.ForceStop, OK")
 }
@@ -146,6 +274,55 @@ func (client *EurekaClient) ChangeMetadata(metadata map[string]string) *CommonRe
     return ret.(*CommonResponse)
 }
 
+// SetMetadata 将元数据整体变更为metadata(仅发送变更的key, 删除key时重新注册服务实例)
+func (client *EurekaClient) SetMetadata(metadata map[string]string) *CommonResponse {
+    ret, err := client.exec("SetMetadata", func(params ...any) (any, error) {
+        return client.registryClient.SetMetadata(params[0].(map[string]string)), nil
+    }, metadata)
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    return ret.(*CommonResponse)
+}
+
+// DeleteMetadata 删除元数据key
+func (client *EurekaClient) DeleteMetadata(keys ...string) *CommonResponse {
+    ret, err := client.exec("DeleteMetadata", func(params ...any) (any, error) {
+        return client.registryClient.DeleteMetadata(params[0].([]string)...), nil
+    }, keys)
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    return ret.(*CommonResponse)
+}
+
+// ReRegister 使用当前服务状态重新注册服务实例
+func (client *EurekaClient) ReRegister() *CommonResponse {
+    ret, err := client.exec("ReRegister", func(params ...any) (any, error) {
+        response := client.registryClient.ReRegister()
+        client.onHeartbeat(response)
+        return response, nil
+    })
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    return ret.(*CommonResponse)
+}
+
+// FetchApps 立即从eureka server拉取服务列表并刷新服务发现缓存
+func (client *EurekaClient) FetchApps() (map[string][]*meta.AppInfo, error) {
+    ret, err := client.exec("FetchApps", func(params ...any) (any, error) {
+        if _, err := client.discoveryClient.isEnabled(); err != nil {
+            return nil, err
+        }
+        return client.discoveryClient.Discovery0()
+    })
+    if err != nil {
+        return nil, err
+    }
+    return ret.(map[string][]*meta.AppInfo), nil
+}
+
 // AccessApp 查询可用服务信息
 func (client *EurekaClient) AccessApp(appName string) (*meta.AppInfo, error) {
     ret, err := client.exec("AccessApp", func(params ...any) (any, error) {
@@ -245,6 +422,17 @@ func (client *EurekaClient) AccessInstanceBySvip(svip string) (*meta.InstanceInf
     return ret.(*meta.InstanceInfo), nil
 }
 
+// AccessRoutedInstance 根据路由规则查询可用服务实例（随机选择）
+func (client *EurekaClient) AccessRoutedInstance(appName string) (*meta.InstanceInfo, error) {
+    ret, err := client.exec("AccessRoutedInstance", func(params ...any) (any, error) {
+        return client.router.Select(params[0].(string))
+    }, appName)
+    if err != nil {
+        return nil, err
+    }
+    return ret.(*meta.InstanceInfo), nil
+}
+
 // exec 处理并返回（同步检查当前客户端运行状态状态）
 func (client *EurekaClient) exec(name string, r func(params ...any) (any, error), params ...any) (ret any, err error) {
     defer func() {
@@ -268,15 +456,18 @@ func (client *EurekaClient) exec(name string, r func(params ...any) (any, error)
         }
         client.GetLogger().Tracef("EurekaClient.%s, PARAMS >>> "+strings.Join(sl, ", "), sp...)
     }
-    if client.ctx != nil {
-        select {
-        case <-client.ctx.Done():
-            return nil, clientHasBeenStoppedErr()
-        default:
-            return r(params...)
-        }
+    switch client.State() {
+    case StateCreated, StateStarting:
+        return nil, clientNotStartedErr()
+    case StateStopped:
+        return nil, clientHasBeenStoppedErr()
+    }
+    select {
+    case <-client.ctx.Done():
+        return nil, clientHasBeenStoppedErr()
+    default:
+        return r(params...)
     }
-    return nil, clientNotStartedErr()
 }
 
 // RegistryClient 获取与eureka通讯的 *RegistryClient
@@ -289,6 +480,16 @@ func (client *EurekaClient) DiscoveryClient() *DiscoveryClient {
     return client.discoveryClient
 }
 
+// Router 获取基于元数据的服务实例路由 *Router
+func (client *EurekaClient) Router() *Router {
+    return client.router
+}
+
+// Registrations 获取附加服务实例注册管理 *RegistrationManager
+func (client *EurekaClient) Registrations() *RegistrationManager {
+    return client.registrations
+}
+
 // HttpClient 获取与eureka通讯的 *HttpClient
 func (client *EurekaClient) HttpClient() *HttpClient {
     return client.httpClient
@@ -303,6 +504,7 @@ func (client *EurekaClient) SetLogger(logger log.Logger) error {
     client.httpClient.Logger = logger
     client.registryClient.Logger = logger
     client.discoveryClient.Logger = logger
+    client.registrations.Logger = logger
     return nil
 }
 
@@ -341,7 +543,13 @@ func NewEurekaClientWithOptions(config *meta.EurekaConfig, options *EurekaConfig
     }
     logger := log.DefaultLoggerImpl
     httpClient := &HttpClient{Logger: logger}
-    return &EurekaClient{
+    discoveryClient := &DiscoveryClient{
+        HttpClient: httpClient,
+        Config:     newConfig,
+        Logger:     logger,
+        Apps:       make(map[string][]*meta.AppInfo),
+    }
+    client = &EurekaClient{
         UUID:       strings.ReplaceAll(uuid.New().String(), "-", ""),
         config:     newConfig,
         rootCtx:    nil,
@@ -354,12 +562,12 @@ func NewEurekaClientWithOptions(config *meta.EurekaConfig, options *EurekaConfig
             Logger:        logger,
             HeartbeatFunc: options.HeartbeatFunc,
         },
-        discoveryClient: &DiscoveryClient{
-            HttpClient: httpClient,
-            Config:     newConfig,
-            Logger:     logger,
-            Apps:       make(map[string][]*meta.AppInfo),
-        },
-        logger: logger,
-    }, nil
+        discoveryClient: discoveryClient,
+        router:          NewRouter(discoveryClient),
+        logger:          logger,
+        state:           StateCreated,
+    }
+    client.registryClient.onHeartbeat = client.onHeartbeat
+    client.registrations = &RegistrationManager{client: client, Logger: logger}
+    return client, nil
 }
@@ -1,11 +1,14 @@
 package client
 
 import (
+    "context"
     "fmt"
     "github.com/google/uuid"
     "github.com/jiashunx/eureka-client-go/log"
     "github.com/jiashunx/eureka-client-go/meta"
     "github.com/stretchr/testify/assert"
+    "net/http"
+    "strings"
     "testing"
     "time"
 )
@@ -250,3 +253,216 @@ func TestEurekaClient_Case3(t *testing.T) {
     ast.Nilf(response.Error, "%v", response)
 
 }
+
+// TestEurekaClient_GracefulStop 优雅关闭: 下线->等待排空->取消注册->等待后台goroutine退出
+func TestEurekaClient_GracefulStop(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:       "graceful-stop-test",
+            InstanceId:    "127.0.0.1:28084",
+            NonSecurePort: 28084,
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.NotNil(server.Instance("graceful-stop-test", "127.0.0.1:28084"))
+
+    inflight := 3
+    start := time.Now()
+    response = client.GracefulStop(context.Background(), &GracefulStopOptions{
+        DrainPeriod:        50 * time.Millisecond,
+        DrainCheckInterval: 10 * time.Millisecond,
+        Drained: func() bool {
+            inflight--
+            return inflight <= 0
+        },
+    })
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.True(time.Since(start) < 5*time.Second)
+    ast.Equal(0, inflight)
+    ast.Nil(server.Instance("graceful-stop-test", "127.0.0.1:28084"))
+
+    requests := server.Requests()
+    statusIdx, deleteIdx := -1, -1
+    for idx, request := range requests {
+        if request == "PUT /apps/graceful-stop-test/127.0.0.1:28084/status?value=OUT_OF_SERVICE" {
+            statusIdx = idx
+        }
+        if request == "DELETE /apps/graceful-stop-test/127.0.0.1:28084" {
+            deleteIdx = idx
+        }
+    }
+    ast.True(statusIdx >= 0 && deleteIdx > statusIdx, "%v", requests)
+
+    // 关闭后客户端不可用
+    ast.NotNil(client.ChangeStatus(meta.StatusUp).Error)
+    ast.NotNil(client.GracefulStop(nil, nil).Error)
+}
+
+// TestEurekaClient_State 客户端生命周期状态变更及监听
+func TestEurekaClient_State(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:                       "state-test",
+            InstanceId:                    "127.0.0.1:28086",
+            NonSecurePort:                 28086,
+            LeaseRenewalIntervalInSeconds: 1,
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    ast.Equal(StateCreated, client.State())
+    transitions := make(chan string, 16)
+    client.AddStateListener(func(client *EurekaClient, from, to ClientState) {
+        transitions <- string(from) + "->" + string(to)
+    })
+    waitState := func(state ClientState) {
+        timeout := time.After(5 * time.Second)
+        for client.State() != state {
+            select {
+            case <-timeout:
+                ast.FailNow("timed out waiting for state", "%s, actual: %s", state, client.State())
+            case <-time.After(10 * time.Millisecond):
+            }
+        }
+    }
+
+    // 注册失败时启动失败, 状态变更为已关闭
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusInternalServerError)
+        return true
+    })
+    ast.NotNil(client.Start().Error)
+    ast.Equal(StateStopped, client.State())
+    ast.Equal("CREATED->STARTING", <-transitions)
+    ast.Equal("STARTING->STOPPED", <-transitions)
+
+    // 启动成功
+    server.Intercept(nil)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(StateRegistered, client.State())
+    ast.True(client.State().IsRunning())
+    ast.NotNil(client.Start().Error)
+    ast.Equal("STOPPED->STARTING", <-transitions)
+    ast.Equal("STARTING->REGISTERED", <-transitions)
+
+    // 心跳失败
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        if r.Method == http.MethodPut {
+            w.WriteHeader(http.StatusNotFound)
+            return true
+        }
+        return false
+    })
+    waitState(StateRegistrationFailing)
+    ast.Equal("REGISTERED->REGISTRATION_FAILING", <-transitions)
+
+    // 心跳恢复
+    server.Intercept(nil)
+    waitState(StateRegistered)
+    ast.Equal("REGISTRATION_FAILING->REGISTERED", <-transitions)
+
+    response = client.Stop()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(StateStopped, client.State())
+    ast.Equal("REGISTERED->STOPPING", <-transitions)
+    ast.Equal("STOPPING->STOPPED", <-transitions)
+}
+
+// TestEurekaClient_StateWithCtx 外部context取消时状态变更为已关闭
+func TestEurekaClient_StateWithCtx(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "state-test"},
+        ClientConfig:   &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL, DiscoveryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    ctx, cancel := context.WithCancel(context.Background())
+    response := client.StartWithCtx(ctx)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(StateRegistered, client.State())
+    cancel()
+    timeout := time.After(5 * time.Second)
+    for client.State() != StateStopped {
+        select {
+        case <-timeout:
+            ast.FailNow("timed out waiting for state", "actual: %s", client.State())
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+    ast.NotNil(client.ChangeStatus(meta.StatusUp).Error)
+    ast.Nil(client.Start().Error)
+    ast.Nil(client.Stop().Error)
+    ast.Equal(StateStopped, client.State())
+}
+
+// testPanicLogger 错误日志内容首次包含指定关键字时panic
+type testPanicLogger struct {
+    log.Logger
+    keyword  string
+    panicked bool
+}
+
+// Errorf 错误日志内容首次包含指定关键字时panic
+func (logger *testPanicLogger) Errorf(format string, a ...any) {
+    if !logger.panicked && strings.Contains(fmt.Sprintf(format, a...), logger.keyword) {
+        logger.panicked = true
+        panic(logger.keyword)
+    }
+    logger.Logger.Errorf(format, a...)
+}
+
+// TestEurekaClient_StartPanic 启动过程panic时停止后台任务, 状态变更为已关闭
+func TestEurekaClient_StartPanic(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "state-test"},
+        ClientConfig:   &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL, DiscoveryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    client.logger = &testPanicLogger{Logger: log.DefaultLoggerImpl, keyword: "failed to start registry client"}
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusInternalServerError)
+        return true
+    })
+    response := client.Start()
+    ast.NotNil(response.Error)
+    ast.Contains(response.Error.Error(), "recover error")
+    ast.Equal(StateStopped, client.State())
+    ast.NotNil(client.ctx.Err())
+
+    server.Intercept(nil)
+    ast.Nil(client.Start().Error)
+    ast.Equal(StateRegistered, client.State())
+    ast.Nil(client.Stop().Error)
+}
+
+// TestEurekaClient_StartRegistryDisabled 未开启服务注册时启动失败
+func TestEurekaClient_StartRegistryDisabled(t *testing.T) {
+    ast := assert.New(t)
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        ClientConfig: &meta.ClientConfig{RegistryEnabled: &meta.False, DiscoveryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    ast.NotNil(client.Start().Error)
+    ast.Equal(StateStopped, client.State())
+}
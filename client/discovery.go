@@ -9,6 +9,7 @@ import (
     "math/rand"
     "strconv"
     "strings"
+    "sync"
     "time"
 )
 
@@ -19,6 +20,17 @@ type DiscoveryClient struct {
     Logger     log.Logger
     // zone与服务列表映射
     Apps map[string][]*meta.AppInfo
+    // 异常服务实例检测(可选), 设置后选择服务实例时排除已被摘除的服务实例
+    OutlierDetector *OutlierDetector
+    // 服务列表更新监听
+    listeners listenerRegistry[func(Apps map[string][]*meta.AppInfo)]
+    // 后台goroutine, 仅当集成到 EurekaClient 时有效
+    routines sync.WaitGroup
+    // 各zone服务拉取结果记录
+    health discoveryHealth
+    // 各zone上次成功拉取的服务列表(用于条件查询)
+    fetched      map[string]*AppsResponse
+    fetchedMutex sync.Mutex
 }
 
 // GetLogger 获取客户端日志对象
@@ -31,28 +43,42 @@ func (discovery *DiscoveryClient) GetLogger() log.Logger {
 
 // start 启动eureka服务发现客户端
 func (discovery *DiscoveryClient) start(ctx context.Context) *CommonResponse {
+    discovery.routines.Add(1)
     go discovery.discovery(ctx)
     return &CommonResponse{Error: nil}
 }
 
 // discovery 具体服务发现处理逻辑
 func (discovery *DiscoveryClient) discovery(ctx context.Context) {
+    defer discovery.routines.Done()
     ticker := time.NewTicker(time.Duration(discovery.Config.RegistryFetchIntervalSeconds) * time.Second)
-FL:
+    defer ticker.Stop()
     for {
         select {
         case <-ctx.Done():
-            ticker.Stop()
-            break FL
+            return
         default:
             if b, _ := discovery.isEnabled(); b {
-                go discovery.Discovery0()
+                discovery.routines.Add(1)
+                go func() {
+                    defer discovery.routines.Done()
+                    _, _ = discovery.Discovery0()
+                }()
             }
         }
-        <-ticker.C
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
     }
 }
 
+// wait 等待后台goroutine全部退出
+func (discovery *DiscoveryClient) wait() {
+    discovery.routines.Wait()
+}
+
 // Discovery0 具体服务发现处理逻辑
 func (discovery *DiscoveryClient) Discovery0() (Apps map[string][]*meta.AppInfo, err error) {
     defer func() {
@@ -71,11 +97,18 @@ func (discovery *DiscoveryClient) Discovery0() (Apps map[string][]*meta.AppInfo,
     c := make(chan map[string][]*meta.AppInfo)
     for zone, server := range servers {
         go func(zone string, server *meta.EurekaServer) {
-            response := discovery.HttpClient.QueryApps(server)
+            response := discovery.HttpClient.QueryAppsConditional(server, discovery.lastFetched(zone))
+            discovery.health.onFetch(zone, response)
             if response.Error != nil {
                 c <- map[string][]*meta.AppInfo{zone: make([]*meta.AppInfo, 0)}
                 return
             }
+            discovery.setFetched(zone, response)
+            // 服务列表未变化, 复用上次处理结果
+            if response.CacheHit {
+                c <- map[string][]*meta.AppInfo{zone: response.Apps}
+                return
+            }
             for _, app := range response.Apps {
                 app.Region = discovery.Config.Region
                 app.Zone = zone
@@ -96,9 +129,60 @@ func (discovery *DiscoveryClient) Discovery0() (Apps map[string][]*meta.AppInfo,
     close(c)
     discovery.Apps = apps
     discovery.GetLogger().Tracef("DiscoveryClient.Discovery0, OK >>> apps: %v", SummaryAppsMap(apps))
+    discovery.notifyListeners(apps)
     return apps, nil
 }
 
+// lastFetched 获取指定zone上次成功拉取的服务列表
+func (discovery *DiscoveryClient) lastFetched(zone string) *AppsResponse {
+    discovery.fetchedMutex.Lock()
+    defer discovery.fetchedMutex.Unlock()
+    return discovery.fetched[zone]
+}
+
+// setFetched 记录指定zone成功拉取的服务列表
+func (discovery *DiscoveryClient) setFetched(zone string, response *AppsResponse) {
+    discovery.fetchedMutex.Lock()
+    defer discovery.fetchedMutex.Unlock()
+    if discovery.fetched == nil {
+        discovery.fetched = make(map[string]*AppsResponse)
+    }
+    discovery.fetched[zone] = response
+}
+
+// Subscribe 订阅服务列表更新(每次从eureka server获取服务列表后回调), 返回取消订阅函数
+func (discovery *DiscoveryClient) Subscribe(listener func(Apps map[string][]*meta.AppInfo)) (unsubscribe func()) {
+    return discovery.listeners.add(listener)
+}
+
+// notifyListeners 通知服务列表更新
+func (discovery *DiscoveryClient) notifyListeners(Apps map[string][]*meta.AppInfo) {
+    discovery.listeners.notify(discovery.GetLogger(), "DiscoveryClient.notifyListeners", func(listener func(Apps map[string][]*meta.AppInfo)) {
+        listener(Apps)
+    })
+}
+
+// availableInstances 获取可用服务实例(排除已被摘除的服务实例, ignoreEjection为true时若全部被摘除则不排除)
+func (discovery *DiscoveryClient) availableInstances(app *meta.AppInfo, ignoreEjection bool) []*meta.InstanceInfo {
+    instances := app.AvailableInstances()
+    if discovery.OutlierDetector != nil {
+        if ignoreEjection {
+            return discovery.OutlierDetector.Filter(instances)
+        }
+        return discovery.OutlierDetector.exclude(instances)
+    }
+    return instances
+}
+
+// withEjectionFallback 先排除已被摘除的服务实例进行查询, 所有zone均无可用服务实例时再忽略摘除结果查询, 避免服务完全不可用
+func withEjectionFallback[T any](discovery *DiscoveryClient, query func(ignoreEjection bool) (T, error)) (T, error) {
+    ret, err := query(false)
+    if err != nil && discovery.OutlierDetector != nil && errors.Is(err, ErrNoAvailableInstance) {
+        return query(true)
+    }
+    return ret, err
+}
+
 // isEnabled 服务发现功能是否开启
 func (discovery *DiscoveryClient) isEnabled() (bool, error) {
     if !*discovery.Config.DiscoveryEnabled {
@@ -250,7 +334,14 @@ func (discovery *DiscoveryClient) AccessInstanceBySvip(svip string) (*meta.Insta
 }
 
 // FilterApp 查询可用服务
-func (discovery *DiscoveryClient) FilterApp(Apps map[string][]*meta.AppInfo, appName string) (app *meta.AppInfo, err error) {
+func (discovery *DiscoveryClient) FilterApp(Apps map[string][]*meta.AppInfo, appName string) (*meta.AppInfo, error) {
+    return withEjectionFallback(discovery, func(ignoreEjection bool) (*meta.AppInfo, error) {
+        return discovery.filterApp(Apps, appName, ignoreEjection)
+    })
+}
+
+// filterApp 查询可用服务(ignoreEjection: 是否忽略异常服务实例摘除结果)
+func (discovery *DiscoveryClient) filterApp(Apps map[string][]*meta.AppInfo, appName string, ignoreEjection bool) (app *meta.AppInfo, err error) {
     if _, err = discovery.isEnabled(); err != nil {
         return nil, err
     }
@@ -258,7 +349,7 @@ func (discovery *DiscoveryClient) FilterApp(Apps map[string][]*meta.AppInfo, app
     if *Config.PreferSameZoneEureka && Apps != nil {
         if apps, ok := Apps[Config.Zone]; ok {
             app = FilterApp(apps, appName)
-            instances := app.AvailableInstances()
+            instances := discovery.availableInstances(app, ignoreEjection)
             if instances != nil && len(instances) > 0 {
                 return app.CopyWithInstances(instances), nil
             }
@@ -274,7 +365,7 @@ func (discovery *DiscoveryClient) FilterApp(Apps map[string][]*meta.AppInfo, app
     err = RandomLoopMap(anyMap, func(k string, v interface{}) (bool, error) {
         if v != nil {
             app = FilterApp(v.([]*meta.AppInfo), appName)
-            instances := app.AvailableInstances()
+            instances := discovery.availableInstances(app, ignoreEjection)
             if instances != nil && len(instances) > 0 {
                 app = app.CopyWithInstances(instances)
                 return false, nil
@@ -284,7 +375,7 @@ func (discovery *DiscoveryClient) FilterApp(Apps map[string][]*meta.AppInfo, app
         return true, nil
     })
     if err == nil && app == nil {
-        err = errors.New("no available service found")
+        err = newNoAvailableError("no available service found")
     }
     return app, err
 }
@@ -299,7 +390,14 @@ func (discovery *DiscoveryClient) FilterAppInstance(Apps map[string][]*meta.AppI
 }
 
 // FilterAppsByVip 查询指定vip的可用服务列表
-func (discovery *DiscoveryClient) FilterAppsByVip(Apps map[string][]*meta.AppInfo, vip string) (vipApps []*meta.AppInfo, err error) {
+func (discovery *DiscoveryClient) FilterAppsByVip(Apps map[string][]*meta.AppInfo, vip string) ([]*meta.AppInfo, error) {
+    return withEjectionFallback(discovery, func(ignoreEjection bool) ([]*meta.AppInfo, error) {
+        return discovery.filterAppsByVip(Apps, vip, ignoreEjection)
+    })
+}
+
+// filterAppsByVip 查询指定vip的可用服务列表(ignoreEjection: 是否忽略异常服务实例摘除结果)
+func (discovery *DiscoveryClient) filterAppsByVip(Apps map[string][]*meta.AppInfo, vip string, ignoreEjection bool) (vipApps []*meta.AppInfo, err error) {
     if _, err = discovery.isEnabled(); err != nil {
         return nil, err
     }
@@ -310,7 +408,7 @@ func (discovery *DiscoveryClient) FilterAppsByVip(Apps map[string][]*meta.AppInf
             if vipApps != nil && len(vipApps) > 0 {
                 accessApps := make([]*meta.AppInfo, 0)
                 for _, vipApp := range vipApps {
-                    instances := vipApp.AvailableInstances()
+                    instances := discovery.availableInstances(vipApp, ignoreEjection)
                     if instances != nil && len(instances) > 0 {
                         accessApps = append(accessApps, vipApp.CopyWithInstances(instances))
                     }
@@ -334,7 +432,7 @@ func (discovery *DiscoveryClient) FilterAppsByVip(Apps map[string][]*meta.AppInf
             if vipApps != nil && len(vipApps) > 0 {
                 accessApps := make([]*meta.AppInfo, 0)
                 for _, vipApp := range vipApps {
-                    instances := vipApp.AvailableInstances()
+                    instances := discovery.availableInstances(vipApp, ignoreEjection)
                     if instances != nil && len(instances) > 0 {
                         accessApps = append(accessApps, vipApp.CopyWithInstances(instances))
                     }
@@ -349,7 +447,7 @@ func (discovery *DiscoveryClient) FilterAppsByVip(Apps map[string][]*meta.AppInf
         return true, nil
     })
     if err == nil && (vipApps == nil || len(vipApps) == 0) {
-        err = errors.New("no available service found")
+        err = newNoAvailableError("no available service found")
     }
     return vipApps, err
 }
@@ -365,7 +463,14 @@ func (discovery *DiscoveryClient) FilterAppInstanceByVip(Apps map[string][]*meta
 }
 
 // FilterAppsBySvip 查询指定svip的可用服务列表
-func (discovery *DiscoveryClient) FilterAppsBySvip(Apps map[string][]*meta.AppInfo, svip string) (svipApps []*meta.AppInfo, err error) {
+func (discovery *DiscoveryClient) FilterAppsBySvip(Apps map[string][]*meta.AppInfo, svip string) ([]*meta.AppInfo, error) {
+    return withEjectionFallback(discovery, func(ignoreEjection bool) ([]*meta.AppInfo, error) {
+        return discovery.filterAppsBySvip(Apps, svip, ignoreEjection)
+    })
+}
+
+// filterAppsBySvip 查询指定svip的可用服务列表(ignoreEjection: 是否忽略异常服务实例摘除结果)
+func (discovery *DiscoveryClient) filterAppsBySvip(Apps map[string][]*meta.AppInfo, svip string, ignoreEjection bool) (svipApps []*meta.AppInfo, err error) {
     if _, err = discovery.isEnabled(); err != nil {
         return nil, err
     }
@@ -376,7 +481,7 @@ func (discovery *DiscoveryClient) FilterAppsBySvip(Apps map[string][]*meta.AppIn
             if svipApps != nil && len(svipApps) > 0 {
                 accessApps := make([]*meta.AppInfo, 0)
                 for _, svipApp := range svipApps {
-                    instances := svipApp.AvailableInstances()
+                    instances := discovery.availableInstances(svipApp, ignoreEjection)
                     if instances != nil && len(instances) > 0 {
                         accessApps = append(accessApps, svipApp.CopyWithInstances(instances))
                     }
@@ -400,7 +505,7 @@ func (discovery *DiscoveryClient) FilterAppsBySvip(Apps map[string][]*meta.AppIn
             if svipApps != nil && len(svipApps) > 0 {
                 accessApps := make([]*meta.AppInfo, 0)
                 for _, svipApp := range svipApps {
-                    instances := svipApp.AvailableInstances()
+                    instances := discovery.availableInstances(svipApp, ignoreEjection)
                     if instances != nil && len(instances) > 0 {
                         accessApps = append(accessApps, svipApp.CopyWithInstances(instances))
                     }
@@ -415,7 +520,7 @@ func (discovery *DiscoveryClient) FilterAppsBySvip(Apps map[string][]*meta.AppIn
         return true, nil
     })
     if err == nil && (svipApps == nil || len(svipApps) == 0) {
-        err = errors.New("no available service found")
+        err = newNoAvailableError("no available service found")
     }
     return svipApps, err
 }
@@ -431,7 +536,14 @@ func (discovery *DiscoveryClient) FilterAppInstanceBySvip(Apps map[string][]*met
 }
 
 // FilterInstancesByVip 查询指定vip的可用服务实例列表
-func (discovery *DiscoveryClient) FilterInstancesByVip(Apps map[string][]*meta.AppInfo, vip string) (instances []*meta.InstanceInfo, err error) {
+func (discovery *DiscoveryClient) FilterInstancesByVip(Apps map[string][]*meta.AppInfo, vip string) ([]*meta.InstanceInfo, error) {
+    return withEjectionFallback(discovery, func(ignoreEjection bool) ([]*meta.InstanceInfo, error) {
+        return discovery.filterInstancesByVip(Apps, vip, ignoreEjection)
+    })
+}
+
+// filterInstancesByVip 查询指定vip的可用服务实例列表(ignoreEjection: 是否忽略异常服务实例摘除结果)
+func (discovery *DiscoveryClient) filterInstancesByVip(Apps map[string][]*meta.AppInfo, vip string, ignoreEjection bool) (instances []*meta.InstanceInfo, err error) {
     if _, err = discovery.isEnabled(); err != nil {
         return nil, err
     }
@@ -441,7 +553,7 @@ func (discovery *DiscoveryClient) FilterInstancesByVip(Apps map[string][]*meta.A
             instances = FilterInstancesByVip(apps, vip)
             if instances != nil && len(instances) > 0 {
                 tmpApp := &meta.AppInfo{Instances: instances}
-                instances = tmpApp.AvailableInstances()
+                instances = discovery.availableInstances(tmpApp, ignoreEjection)
                 if instances != nil && len(instances) > 0 {
                     return instances, nil
                 }
@@ -460,7 +572,7 @@ func (discovery *DiscoveryClient) FilterInstancesByVip(Apps map[string][]*meta.A
             instances = FilterInstancesByVip(v.([]*meta.AppInfo), vip)
             if instances != nil && len(instances) > 0 {
                 tmpApp := &meta.AppInfo{Instances: instances}
-                instances = tmpApp.AvailableInstances()
+                instances = discovery.availableInstances(tmpApp, ignoreEjection)
                 if instances != nil && len(instances) > 0 {
                     return false, nil
                 }
@@ -470,7 +582,7 @@ func (discovery *DiscoveryClient) FilterInstancesByVip(Apps map[string][]*meta.A
         return true, nil
     })
     if err == nil && (instances == nil || len(instances) == 0) {
-        err = errors.New("no available service instance found")
+        err = newNoAvailableError("no available service instance found")
     }
     return instances, err
 }
@@ -485,7 +597,14 @@ func (discovery *DiscoveryClient) FilterInstanceByVip(Apps map[string][]*meta.Ap
 }
 
 // FilterInstancesBySvip 查询指定svip的可用服务实例列表
-func (discovery *DiscoveryClient) FilterInstancesBySvip(Apps map[string][]*meta.AppInfo, svip string) (instances []*meta.InstanceInfo, err error) {
+func (discovery *DiscoveryClient) FilterInstancesBySvip(Apps map[string][]*meta.AppInfo, svip string) ([]*meta.InstanceInfo, error) {
+    return withEjectionFallback(discovery, func(ignoreEjection bool) ([]*meta.InstanceInfo, error) {
+        return discovery.filterInstancesBySvip(Apps, svip, ignoreEjection)
+    })
+}
+
+// filterInstancesBySvip 查询指定svip的可用服务实例列表(ignoreEjection: 是否忽略异常服务实例摘除结果)
+func (discovery *DiscoveryClient) filterInstancesBySvip(Apps map[string][]*meta.AppInfo, svip string, ignoreEjection bool) (instances []*meta.InstanceInfo, err error) {
     if _, err = discovery.isEnabled(); err != nil {
         return nil, err
     }
@@ -495,7 +614,7 @@ func (discovery *DiscoveryClient) FilterInstancesBySvip(Apps map[string][]*meta.
             instances = FilterInstancesBySvip(apps, svip)
             if instances != nil && len(instances) > 0 {
                 tmpApp := &meta.AppInfo{Instances: instances}
-                instances = tmpApp.AvailableInstances()
+                instances = discovery.availableInstances(tmpApp, ignoreEjection)
                 if instances != nil && len(instances) > 0 {
                     return instances, nil
                 }
@@ -514,7 +633,7 @@ func (discovery *DiscoveryClient) FilterInstancesBySvip(Apps map[string][]*meta.
             instances = FilterInstancesBySvip(v.([]*meta.AppInfo), svip)
             if instances != nil && len(instances) > 0 {
                 tmpApp := &meta.AppInfo{Instances: instances}
-                instances = tmpApp.AvailableInstances()
+                instances = discovery.availableInstances(tmpApp, ignoreEjection)
                 if instances != nil && len(instances) > 0 {
                     return false, nil
                 }
@@ -524,7 +643,7 @@ func (discovery *DiscoveryClient) FilterInstancesBySvip(Apps map[string][]*meta.
         return true, nil
     })
     if err == nil && (instances == nil || len(instances) == 0) {
-        err = errors.New("no available service instance")
+        err = newNoAvailableError("no available service instance")
     }
     return instances, err
 }
@@ -0,0 +1,121 @@
+package client
+
+import (
+    "errors"
+    "net/http"
+)
+
+var (
+    // ErrInstanceNotFound eureka server返回404(服务或服务实例不存在)
+    ErrInstanceNotFound = errors.New("eureka: instance not found")
+    // ErrUnauthorized eureka server返回401或403(认证失败或无权限)
+    ErrUnauthorized = errors.New("eureka: unauthorized")
+    // ErrServerUnavailable eureka server不可用(连接失败、读取响应失败或返回5xx)
+    ErrServerUnavailable = errors.New("eureka: server unavailable")
+    // ErrDecode eureka server响应内容解析失败
+    ErrDecode = errors.New("eureka: failed to decode response")
+    // ErrClientNotStarted eureka客户端未启动
+    ErrClientNotStarted = errors.New("eureka client has not been started")
+    // ErrClientStopped eureka客户端已关闭
+    ErrClientStopped = errors.New("eureka client has already been stopped")
+    // ErrNoAvailableInstance 无可用服务或服务实例
+    ErrNoAvailableInstance = errors.New("eureka: no available instance")
+    // ErrCircuitOpen eureka server服务地址处于熔断状态, 未发送请求
+    ErrCircuitOpen = errors.New("eureka: circuit breaker is open")
+)
+
+// HttpError 与eureka server通讯错误, 可通过 errors.Is 判断错误分类(如: ErrInstanceNotFound), 通过 errors.As 获取详细信息
+type HttpError struct {
+    // 错误分类: ErrInstanceNotFound, ErrUnauthorized, ErrServerUnavailable, ErrDecode, 无法分类时为nil
+    Kind error
+    // http响应码, 未收到响应时为0
+    StatusCode int
+    // eureka server服务地址
+    ServiceUrl string
+    // 请求方法
+    Method string
+    // 请求地址
+    RequestUrl string
+    // 同批次所有通讯响应(依次请求各eureka server服务地址)
+    Responses []*EurekaResponse
+    // 原始错误
+    Err error
+}
+
+// Error 错误信息
+func (err *HttpError) Error() string {
+    if err.Err != nil {
+        return err.Err.Error()
+    }
+    if err.Kind != nil {
+        return err.Kind.Error()
+    }
+    return "eureka: http error"
+}
+
+// Unwrap 返回错误分类及原始错误, 供 errors.Is/errors.As 使用
+func (err *HttpError) Unwrap() []error {
+    errs := make([]error, 0, 2)
+    if err.Kind != nil {
+        errs = append(errs, err.Kind)
+    }
+    if err.Err != nil {
+        errs = append(errs, err.Err)
+    }
+    return errs
+}
+
+// classifyStatusCode 根据http响应码获取错误分类
+func classifyStatusCode(statusCode int) error {
+    switch {
+    case statusCode == http.StatusNotFound:
+        return ErrInstanceNotFound
+    case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+        return ErrUnauthorized
+    case statusCode >= http.StatusInternalServerError:
+        return ErrServerUnavailable
+    }
+    return nil
+}
+
+// newHttpError 根据通讯请求创建 *HttpError
+func newHttpError(kind error, request *EurekaRequest, statusCode int, err error) *HttpError {
+    httpError := &HttpError{Kind: kind, StatusCode: statusCode, Err: err}
+    if request != nil {
+        httpError.ServiceUrl = request.ServiceUrl
+        httpError.Method = request.Method
+        httpError.RequestUrl = request.RequestUrl
+    }
+    return httpError
+}
+
+// newDecodeError 创建响应内容解析错误
+func newDecodeError(response *EurekaResponse, err error) error {
+    statusCode := 0
+    if response.HttpResponse != nil {
+        statusCode = response.HttpResponse.StatusCode
+    }
+    httpError := newHttpError(ErrDecode, response.Request, statusCode, err)
+    httpError.Responses = response.Responses
+    return httpError
+}
+
+// noAvailableError 无可用服务或服务实例错误(保留原错误信息)
+type noAvailableError struct {
+    message string
+}
+
+// Error 错误信息
+func (err *noAvailableError) Error() string {
+    return err.message
+}
+
+// Is 是否为 ErrNoAvailableInstance
+func (err *noAvailableError) Is(target error) bool {
+    return target == ErrNoAvailableInstance
+}
+
+// newNoAvailableError 创建无可用服务或服务实例错误
+func newNoAvailableError(message string) error {
+    return &noAvailableError{message: message}
+}
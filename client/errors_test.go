@@ -0,0 +1,108 @@
+package client
+
+import (
+    "errors"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func TestHttpError_Classification(t *testing.T) {
+    ast := assert.New(t)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/apps/MISSING", "/apps/MISSING/instance-1":
+            w.WriteHeader(http.StatusNotFound)
+        case "/apps/SECURE/instance-1":
+            w.WriteHeader(http.StatusUnauthorized)
+        case "/apps/BROKEN/instance-1":
+            w.WriteHeader(http.StatusInternalServerError)
+        case "/apps":
+            w.Header().Set("Content-Type", "application/json")
+            _, _ = w.Write([]byte(`{"unexpected": true}`))
+        case "/apps/GARBAGE":
+            _, _ = w.Write([]byte(`not json`))
+        default:
+            w.WriteHeader(http.StatusBadRequest)
+        }
+    }))
+    defer server.Close()
+    client := &HttpClient{}
+
+    // 404
+    response := client.SimpleHeartbeat(server.URL, "MISSING", "instance-1")
+    ast.True(errors.Is(response.Error, ErrInstanceNotFound))
+    ast.False(errors.Is(response.Error, ErrServerUnavailable))
+    var httpError *HttpError
+    ast.True(errors.As(response.Error, &httpError))
+    ast.Equal(http.StatusNotFound, httpError.StatusCode)
+    ast.Equal(server.URL, httpError.ServiceUrl)
+    ast.Equal(http.MethodPut, httpError.Method)
+    ast.Len(httpError.Responses, 1)
+    ast.Contains(response.Error.Error(), "expect: 200, actual: 404")
+
+    // 401
+    response = client.SimpleHeartbeat(server.URL, "SECURE", "instance-1")
+    ast.True(errors.Is(response.Error, ErrUnauthorized))
+
+    // 5xx
+    response = client.SimpleHeartbeat(server.URL, "BROKEN", "instance-1")
+    ast.True(errors.Is(response.Error, ErrServerUnavailable))
+
+    // 无法分类
+    response = client.SimpleHeartbeat(server.URL, "OTHER", "instance-1")
+    ast.True(errors.As(response.Error, &httpError))
+    ast.Nil(httpError.Kind)
+    ast.Equal(http.StatusBadRequest, httpError.StatusCode)
+
+    // 所有eureka server均不可用, 保留每次通讯响应
+    closedUrl := newTestClosedUrl(ast)
+    response = client.SimpleHeartbeat(closedUrl+","+closedUrl+"/eureka", "ANY", "instance-1")
+    ast.True(errors.Is(response.Error, ErrServerUnavailable))
+    ast.True(errors.As(response.Error, &httpError))
+    ast.Equal(0, httpError.StatusCode)
+    ast.Equal(closedUrl+"/eureka", httpError.ServiceUrl)
+    ast.Len(httpError.Responses, 2)
+    ast.True(errors.Is(httpError.Responses[0].Error, ErrServerUnavailable))
+
+    // 响应内容解析失败
+    appsResponse := client.SimpleQueryApps(server.URL)
+    ast.True(errors.Is(appsResponse.Error, ErrDecode))
+    ast.True(errors.As(appsResponse.Error, &httpError))
+    ast.Equal(http.StatusOK, httpError.StatusCode)
+    ast.Equal(server.URL, httpError.ServiceUrl)
+    instancesResponse := client.SimpleQueryApp(server.URL, "GARBAGE")
+    ast.True(errors.Is(instancesResponse.Error, ErrDecode))
+    instancesResponse = client.SimpleQueryApp(server.URL, "MISSING")
+    ast.True(errors.Is(instancesResponse.Error, ErrInstanceNotFound))
+    ast.False(errors.Is(instancesResponse.Error, ErrDecode))
+}
+
+func TestEurekaClient_Errors(t *testing.T) {
+    ast := assert.New(t)
+    client, err := NewEurekaClient(&meta.EurekaConfig{})
+    ast.Nilf(err, "%v", err)
+    ast.True(errors.Is(client.ChangeStatus(meta.StatusUp).Error, ErrClientNotStarted))
+
+    client = newTestEurekaClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {{Name: "ORDER", Instances: []*meta.InstanceInfo{
+            newTestQueryInstance("ORDER", "order-1", "host-1", "zone1", meta.StatusDown, nil, time.Now()),
+        }}},
+    })
+    _, err = client.AccessApp("ORDER")
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+    _, err = client.AccessInstanceByVip("missing")
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+    _, err = client.AccessRoutedInstance("ORDER")
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+    _, err = client.DiscoveryClient().Query().App("ORDER").Status(meta.StatusUp).First()
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+    _, err = (&http.Client{Transport: NewDiscoveryRoundTripper(client, nil)}).Get("http://ORDER/hello")
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+
+    client.ForceStop()
+    ast.True(errors.Is(client.ChangeStatus(meta.StatusUp).Error, ErrClientStopped))
+}
@@ -0,0 +1,337 @@
+package client
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// DefaultHealthMaxHeartbeatFailures 默认就绪检查允许的心跳连续失败次数上限
+var DefaultHealthMaxHeartbeatFailures = 3
+
+// HealthOptions 健康检查阈值配置
+type HealthOptions struct {
+    // 距上次成功注册/心跳的最长时间, 超过时就绪检查失败, 默认: 3倍心跳间隔, 小于0时不检查
+    MaxHeartbeatAge time.Duration
+    // 心跳连续失败次数达到该值时就绪检查失败, 默认: DefaultHealthMaxHeartbeatFailures, 小于0时不检查
+    MaxHeartbeatFailures int
+    // 服务发现缓存最长时间(以最久未成功拉取的zone计算), 超过时就绪检查失败, 默认: 3倍服务拉取间隔, 小于0时不检查
+    MaxCacheAge time.Duration
+    // 服务发现缓存服务实例数下限, 低于该值时就绪检查失败, 默认: 0(不检查)
+    MinCacheSize int
+}
+
+// HealthStatus eureka客户端健康状态
+type HealthStatus struct {
+    // 就绪检查是否通过
+    Ready bool `json:"ready"`
+    // 存活检查是否通过
+    Live bool `json:"live"`
+    // 客户端生命周期状态
+    State ClientState `json:"state"`
+    // 当前使用的eureka server服务地址
+    Endpoint string `json:"endpoint,omitempty"`
+    // 检查未通过原因
+    Reasons      []string            `json:"reasons,omitempty"`
+    Registration *RegistrationHealth `json:"registration"`
+    Discovery    *DiscoveryHealth    `json:"discovery"`
+}
+
+// RegistrationHealth 服务注册健康状态
+type RegistrationHealth struct {
+    Enabled                  bool                `json:"enabled"`
+    InstanceStatus           meta.InstanceStatus `json:"instanceStatus,omitempty"`
+    Endpoint                 string              `json:"endpoint,omitempty"`
+    RegisteredTime           *time.Time          `json:"registeredTime,omitempty"`
+    LastHeartbeatTime        *time.Time          `json:"lastHeartbeatTime,omitempty"`
+    LastHeartbeatSuccess     bool                `json:"lastHeartbeatSuccess"`
+    LastHeartbeatError       string              `json:"lastHeartbeatError,omitempty"`
+    LastSuccessHeartbeatTime *time.Time          `json:"lastSuccessHeartbeatTime,omitempty"`
+    HeartbeatFailures        int                 `json:"heartbeatFailures"`
+}
+
+// DiscoveryHealth 服务发现健康状态
+type DiscoveryHealth struct {
+    Enabled bool `json:"enabled"`
+    // 缓存时长(秒), 以最久未成功拉取的zone计算, 从未成功拉取时为-1
+    CacheAgeSeconds float64 `json:"cacheAgeSeconds"`
+    // 缓存服务数
+    AppCount int `json:"appCount"`
+    // 缓存服务实例数
+    InstanceCount int                    `json:"instanceCount"`
+    Zones         map[string]*ZoneHealth `json:"zones"`
+}
+
+// ZoneHealth 指定zone的服务拉取状态
+type ZoneHealth struct {
+    Endpoint             string     `json:"endpoint,omitempty"`
+    LastFetchTime        *time.Time `json:"lastFetchTime,omitempty"`
+    LastFetchError       string     `json:"lastFetchError,omitempty"`
+    LastSuccessFetchTime *time.Time `json:"lastSuccessFetchTime,omitempty"`
+    // 上次拉取实际传输字节数
+    LastFetchBytes int64 `json:"lastFetchBytes"`
+    // 上次拉取是否命中缓存(服务列表未变化)
+    LastFetchCacheHit bool `json:"lastFetchCacheHit"`
+}
+
+// registryHealth 服务注册及心跳结果记录
+type registryHealth struct {
+    mutex                    sync.Mutex
+    endpoint                 string
+    registeredTime           time.Time
+    lastHeartbeatTime        time.Time
+    lastHeartbeatError       error
+    lastSuccessHeartbeatTime time.Time
+    heartbeatFailures        int
+}
+
+// onRegister 记录服务注册结果
+func (health *registryHealth) onRegister(response *CommonResponse) {
+    if response == nil || response.Error != nil {
+        return
+    }
+    health.mutex.Lock()
+    defer health.mutex.Unlock()
+    health.registeredTime = time.Now()
+    health.heartbeatFailures = 0
+    if endpoint := responseEndpoint(response.Response); endpoint != "" {
+        health.endpoint = endpoint
+    }
+}
+
+// onHeartbeat 记录心跳结果(仅记录实际发起的心跳请求)
+func (health *registryHealth) onHeartbeat(response *CommonResponse) {
+    if response == nil || response.Response == nil {
+        return
+    }
+    health.mutex.Lock()
+    defer health.mutex.Unlock()
+    health.lastHeartbeatTime = time.Now()
+    health.lastHeartbeatError = response.Error
+    if response.Error != nil {
+        health.heartbeatFailures++
+        return
+    }
+    health.heartbeatFailures = 0
+    health.lastSuccessHeartbeatTime = health.lastHeartbeatTime
+    if endpoint := responseEndpoint(response.Response); endpoint != "" {
+        health.endpoint = endpoint
+    }
+}
+
+// discoveryHealth 各zone服务拉取结果记录
+type discoveryHealth struct {
+    mutex sync.Mutex
+    zones map[string]*ZoneHealth
+}
+
+// onFetch 记录指定zone服务拉取结果
+func (health *discoveryHealth) onFetch(zone string, response *AppsResponse) {
+    health.mutex.Lock()
+    defer health.mutex.Unlock()
+    if health.zones == nil {
+        health.zones = make(map[string]*ZoneHealth)
+    }
+    record := health.zones[zone]
+    if record == nil {
+        record = &ZoneHealth{}
+        health.zones[zone] = record
+    }
+    now := time.Now()
+    record.LastFetchTime = &now
+    record.LastFetchError = ""
+    record.LastFetchBytes = response.BytesTransferred
+    record.LastFetchCacheHit = response.CacheHit
+    if response.Error != nil {
+        record.LastFetchError = response.Error.Error()
+        return
+    }
+    record.LastSuccessFetchTime = &now
+    if endpoint := responseEndpoint(response.Response); endpoint != "" {
+        record.Endpoint = endpoint
+    }
+}
+
+// snapshot 复制各zone服务拉取结果
+func (health *discoveryHealth) snapshot() map[string]*ZoneHealth {
+    health.mutex.Lock()
+    defer health.mutex.Unlock()
+    zones := make(map[string]*ZoneHealth)
+    for zone, record := range health.zones {
+        copied := *record
+        zones[zone] = &copied
+    }
+    return zones
+}
+
+// responseEndpoint 获取请求成功的eureka server服务地址
+func responseEndpoint(response *EurekaResponse) string {
+    if response == nil || response.Error != nil || response.Request == nil {
+        return ""
+    }
+    return response.Request.ServiceUrl
+}
+
+// timeRef 获取非零时间的指针
+func timeRef(t time.Time) *time.Time {
+    if t.IsZero() {
+        return nil
+    }
+    return &t
+}
+
+// Health 获取eureka客户端健康状态, options 为空时使用默认阈值
+func (client *EurekaClient) Health(options *HealthOptions) *HealthStatus {
+    if options == nil {
+        options = &HealthOptions{}
+    }
+    now := time.Now()
+    state := client.State()
+    status := &HealthStatus{
+        State:        state,
+        Reasons:      make([]string, 0),
+        Registration: client.registrationHealth(),
+        Discovery:    client.discoveryHealth(now),
+    }
+    status.Live = state == StateStarting || state == StateStopping || state.IsRunning()
+    if !state.IsRunning() {
+        status.Reasons = append(status.Reasons, fmt.Sprintf("eureka client state is %s", state))
+    }
+    if registration := status.Registration; registration.Enabled {
+        status.Endpoint = registration.Endpoint
+        maxFailures := options.MaxHeartbeatFailures
+        if maxFailures == 0 {
+            maxFailures = DefaultHealthMaxHeartbeatFailures
+        }
+        if maxFailures > 0 && registration.HeartbeatFailures >= maxFailures {
+            status.Reasons = append(status.Reasons, fmt.Sprintf("heartbeat failed %d times in a row, last error: %s", registration.HeartbeatFailures, registration.LastHeartbeatError))
+        }
+        maxAge := options.MaxHeartbeatAge
+        if maxAge == 0 {
+            maxAge = 3 * time.Duration(client.config.LeaseRenewalIntervalInSeconds) * time.Second
+        }
+        // 仅当服务实例状态为UP时发送心跳
+        if maxAge > 0 && state.IsRunning() && registration.InstanceStatus == meta.StatusUp {
+            last := registration.RegisteredTime
+            if registration.LastSuccessHeartbeatTime != nil && (last == nil || registration.LastSuccessHeartbeatTime.After(*last)) {
+                last = registration.LastSuccessHeartbeatTime
+            }
+            if last == nil {
+                status.Reasons = append(status.Reasons, "eureka client has not been registered")
+            } else if age := now.Sub(*last); age > maxAge {
+                status.Reasons = append(status.Reasons, fmt.Sprintf("no successful heartbeat for %v, threshold: %v", age.Truncate(time.Millisecond), maxAge))
+            }
+        }
+    }
+    if discovery := status.Discovery; discovery.Enabled && state.IsRunning() {
+        if zone := discovery.Zones[client.config.Zone]; status.Endpoint == "" && zone != nil {
+            status.Endpoint = zone.Endpoint
+        }
+        maxAge := options.MaxCacheAge
+        if maxAge == 0 {
+            maxAge = 3 * time.Duration(client.config.RegistryFetchIntervalSeconds) * time.Second
+        }
+        if maxAge > 0 {
+            if discovery.CacheAgeSeconds < 0 {
+                status.Reasons = append(status.Reasons, "discovery cache has not been fetched")
+            } else if age := time.Duration(discovery.CacheAgeSeconds * float64(time.Second)); age > maxAge {
+                status.Reasons = append(status.Reasons, fmt.Sprintf("discovery cache age is %v, threshold: %v", age.Truncate(time.Millisecond), maxAge))
+            }
+        }
+        if discovery.InstanceCount < options.MinCacheSize {
+            status.Reasons = append(status.Reasons, fmt.Sprintf("discovery cache size is %d, threshold: %d", discovery.InstanceCount, options.MinCacheSize))
+        }
+    }
+    status.Ready = len(status.Reasons) == 0
+    return status
+}
+
+// registrationHealth 获取服务注册健康状态
+func (client *EurekaClient) registrationHealth() *RegistrationHealth {
+    registry := client.registryClient
+    registration := &RegistrationHealth{}
+    registration.Enabled, _ = registry.isEnabled()
+    if !registration.Enabled {
+        return registration
+    }
+    registration.InstanceStatus = registry.status
+    health := &registry.health
+    health.mutex.Lock()
+    defer health.mutex.Unlock()
+    registration.Endpoint = health.endpoint
+    registration.RegisteredTime = timeRef(health.registeredTime)
+    registration.LastHeartbeatTime = timeRef(health.lastHeartbeatTime)
+    registration.LastHeartbeatSuccess = !health.lastHeartbeatTime.IsZero() && health.lastHeartbeatError == nil
+    if health.lastHeartbeatError != nil {
+        registration.LastHeartbeatError = health.lastHeartbeatError.Error()
+    }
+    registration.LastSuccessHeartbeatTime = timeRef(health.lastSuccessHeartbeatTime)
+    registration.HeartbeatFailures = health.heartbeatFailures
+    return registration
+}
+
+// discoveryHealth 获取服务发现健康状态
+func (client *EurekaClient) discoveryHealth(now time.Time) *DiscoveryHealth {
+    discovery := client.discoveryClient
+    health := &DiscoveryHealth{CacheAgeSeconds: -1, Zones: make(map[string]*ZoneHealth)}
+    health.Enabled, _ = discovery.isEnabled()
+    if !health.Enabled {
+        return health
+    }
+    health.Zones = discovery.health.snapshot()
+    var oldest *time.Time
+    for _, zone := range health.Zones {
+        if zone.LastSuccessFetchTime == nil {
+            oldest = nil
+            break
+        }
+        if oldest == nil || zone.LastSuccessFetchTime.Before(*oldest) {
+            oldest = zone.LastSuccessFetchTime
+        }
+    }
+    if oldest != nil {
+        health.CacheAgeSeconds = now.Sub(*oldest).Seconds()
+    }
+    for _, apps := range discovery.Apps {
+        health.AppCount += len(apps)
+        for _, app := range apps {
+            health.InstanceCount += len(app.Instances)
+        }
+    }
+    return health
+}
+
+// ReadinessHandler 就绪检查http处理器, 返回JSON格式健康状态, 检查未通过时响应码为503
+func (client *EurekaClient) ReadinessHandler(options *HealthOptions) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        status := client.Health(options)
+        writeHealthStatus(w, status, status.Ready)
+    })
+}
+
+// LivenessHandler 存活检查http处理器, 返回JSON格式健康状态, 客户端未启动或已关闭时响应码为503
+func (client *EurekaClient) LivenessHandler(options *HealthOptions) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        status := client.Health(options)
+        writeHealthStatus(w, status, status.Live)
+    })
+}
+
+// writeHealthStatus 输出JSON格式健康状态
+func writeHealthStatus(w http.ResponseWriter, status *HealthStatus, ok bool) {
+    body, err := json.Marshal(status)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if ok {
+        w.WriteHeader(http.StatusOK)
+    } else {
+        w.WriteHeader(http.StatusServiceUnavailable)
+    }
+    _, _ = w.Write(body)
+}
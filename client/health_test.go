@@ -0,0 +1,185 @@
+package client
+
+import (
+    "encoding/json"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+// serveHealth 调用健康检查处理器并解析响应
+func serveHealth(ast *assert.Assertions, handler http.Handler) (int, *HealthStatus) {
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+    ast.Equal("application/json", recorder.Header().Get("Content-Type"))
+    status := &HealthStatus{}
+    ast.Nil(json.Unmarshal(recorder.Body.Bytes(), status))
+    return recorder.Code, status
+}
+
+func TestEurekaClient_Health(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:                       "health-test",
+            InstanceId:                    "127.0.0.1:28087",
+            NonSecurePort:                 28087,
+            LeaseRenewalIntervalInSeconds: 1,
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone:      server.URL,
+            RegistryFetchIntervalSeconds: 1,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    options := &HealthOptions{MaxHeartbeatFailures: 1}
+    readiness := client.ReadinessHandler(options)
+    liveness := client.LivenessHandler(options)
+
+    // 未启动
+    code, status := serveHealth(ast, readiness)
+    ast.Equal(http.StatusServiceUnavailable, code)
+    ast.Equal(StateCreated, status.State)
+    code, _ = serveHealth(ast, liveness)
+    ast.Equal(http.StatusServiceUnavailable, code)
+
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    timeout := time.After(5 * time.Second)
+    for !client.Health(options).Ready {
+        select {
+        case <-timeout:
+            ast.FailNow("timed out waiting for readiness", "%v", client.Health(options).Reasons)
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+    code, status = serveHealth(ast, readiness)
+    ast.Equal(http.StatusOK, code)
+    ast.True(status.Live)
+    ast.Equal(StateRegistered, status.State)
+    ast.Equal(server.URL, status.Endpoint)
+    ast.True(status.Registration.Enabled)
+    ast.Equal(meta.StatusUp, status.Registration.InstanceStatus)
+    ast.NotNil(status.Registration.RegisteredTime)
+    ast.True(status.Discovery.Enabled)
+    ast.Equal(1, status.Discovery.AppCount)
+    ast.Equal(1, status.Discovery.InstanceCount)
+    ast.GreaterOrEqual(status.Discovery.CacheAgeSeconds, float64(0))
+    ast.NotNil(status.Discovery.Zones[meta.DefaultZone].LastSuccessFetchTime)
+    ast.Equal(server.URL, status.Discovery.Zones[meta.DefaultZone].Endpoint)
+
+    // 服务发现缓存服务实例数低于阈值
+    code, status = serveHealth(ast, client.ReadinessHandler(&HealthOptions{MinCacheSize: 2}))
+    ast.Equal(http.StatusServiceUnavailable, code)
+    ast.Len(status.Reasons, 1)
+
+    // 心跳失败
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        if r.Method == http.MethodPut {
+            w.WriteHeader(http.StatusInternalServerError)
+            return true
+        }
+        return false
+    })
+    timeout = time.After(5 * time.Second)
+    for client.Health(options).Ready {
+        select {
+        case <-timeout:
+            ast.FailNow("timed out waiting for heartbeat failure")
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+    code, status = serveHealth(ast, readiness)
+    ast.Equal(http.StatusServiceUnavailable, code)
+    ast.False(status.Registration.LastHeartbeatSuccess)
+    ast.NotEmpty(status.Registration.LastHeartbeatError)
+    ast.GreaterOrEqual(status.Registration.HeartbeatFailures, 1)
+    code, _ = serveHealth(ast, liveness)
+    ast.Equal(http.StatusOK, code)
+    server.Intercept(nil)
+
+    // 已关闭
+    response = client.Stop()
+    ast.Nilf(response.Error, "%v", response.Error)
+    code, status = serveHealth(ast, liveness)
+    ast.Equal(http.StatusServiceUnavailable, code)
+    ast.Equal(StateStopped, status.State)
+}
+
+func TestEurekaClient_HealthThresholds(t *testing.T) {
+    ast := assert.New(t)
+    client := newTestEurekaClient(ast, map[string][]*meta.AppInfo{})
+    defer client.ctxCancel()
+
+    // 从未拉取服务列表
+    status := client.Health(nil)
+    ast.False(status.Ready)
+    ast.True(status.Live)
+    ast.False(status.Registration.Enabled)
+    ast.Equal(float64(-1), status.Discovery.CacheAgeSeconds)
+
+    // 缓存过期
+    client.discoveryClient.health.onFetch("zone1", &AppsResponse{})
+    status = client.Health(&HealthOptions{MaxCacheAge: time.Nanosecond})
+    ast.False(status.Ready)
+    ast.Len(status.Reasons, 1)
+    status = client.Health(&HealthOptions{MaxCacheAge: -1})
+    ast.True(status.Ready, "%v", status.Reasons)
+    status = client.Health(nil)
+    ast.True(status.Ready, "%v", status.Reasons)
+}
+
+func TestDiscoveryClient_ConditionalFetch(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "discovery-test"},
+        ClientConfig:   &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL, RegistryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    discovery := client.discoveryClient
+    zoneHealth := func() *ZoneHealth {
+        zones := discovery.health.snapshot()
+        ast.Len(zones, 1)
+        for _, zone := range zones {
+            return zone
+        }
+        return nil
+    }
+
+    apps, err := discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.False(zoneHealth().LastFetchCacheHit)
+    ast.Greater(zoneHealth().LastFetchBytes, int64(0))
+    var first *meta.AppInfo
+    for zone, list := range apps {
+        ast.Len(list, 1)
+        ast.Equal(zone, list[0].Zone)
+        first = list[0]
+    }
+
+    // 服务列表未变化时复用上次解析结果
+    apps, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.True(zoneHealth().LastFetchCacheHit)
+    for zone, list := range apps {
+        ast.Same(first, list[0])
+        ast.Equal(zone, list[0].Instances[0].Zone)
+    }
+
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusUp})
+    apps, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.False(zoneHealth().LastFetchCacheHit)
+    for _, list := range apps {
+        ast.Len(list[0].Instances, 2)
+    }
+}
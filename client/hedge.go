@@ -0,0 +1,109 @@
+package client
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/google/uuid"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "strings"
+    "time"
+)
+
+var (
+    // DefaultHedgeDelay 默认发送对冲请求前的等待时长
+    DefaultHedgeDelay       = 200 * time.Millisecond
+    // DefaultHedgeMaxRequests 默认同时进行中的最大请求数(含首次请求)
+    DefaultHedgeMaxRequests = 2
+)
+
+// HedgePolicy 服务发现查询请求(仅: QueryApps, QueryAppsConditional, QueryApp, QueryVipApps, QueryInstance)对冲策略:
+// 当前服务地址在Delay内未响应时向下一个eureka server服务地址发送请求, 采用最先成功的响应并取消其余请求
+type HedgePolicy struct {
+    // 发送对冲请求前的等待时长, 默认: DefaultHedgeDelay
+    Delay time.Duration
+    // 同时进行中的最大请求数(含首次请求), 默认: DefaultHedgeMaxRequests
+    MaxRequests int
+}
+
+// delay 发送对冲请求前的等待时长
+func (policy *HedgePolicy) delay() time.Duration {
+    if policy.Delay <= 0 {
+        return DefaultHedgeDelay
+    }
+    return policy.Delay
+}
+
+// maxRequests 同时进行中的最大请求数
+func (policy *HedgePolicy) maxRequests() int {
+    if policy.MaxRequests <= 0 {
+        return DefaultHedgeMaxRequests
+    }
+    return policy.MaxRequests
+}
+
+// hedgeResult 单个eureka server服务地址请求结果
+type hedgeResult struct {
+    responses []*EurekaResponse
+    done      bool
+}
+
+// doHedgedRequest 对冲请求各eureka server服务地址: 首个请求未在等待时长内结束或已失败时请求下一个服务地址, 采用最先成功(或明确的客户端错误)的结果
+func (client *HttpClient) doHedgedRequest(expect int, server *meta.EurekaServer, method string, uri string, payload []byte, options *requestOptions, serviceUrls []string) []*EurekaResponse {
+    policy := client.HedgePolicy
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    results := make(chan *hedgeResult, len(serviceUrls))
+    responses := make([]*EurekaResponse, 0)
+    next, inflight := 0, 0
+    launch := func() {
+        idx, serviceUrl := next, serviceUrls[next]
+        next++
+        inflight++
+        go func() {
+            defer func() {
+                if rc := recover(); rc != nil {
+                    request := &EurekaRequest{ServiceUrl: serviceUrl, Method: method, RequestUri: uri}
+                    results <- &hedgeResult{responses: []*EurekaResponse{{
+                        UUID:    strings.ReplaceAll(uuid.New().String(), "-", ""),
+                        Request: request,
+                        Error:   errors.New(fmt.Sprintf("HttpClient.doHedgedRequest, recover error: %v", rc)),
+                    }}}
+                }
+            }()
+            rs, done := client.doServiceUrl(ctx, expect, server, method, uri, payload, options, serviceUrl, idx)
+            results <- &hedgeResult{responses: rs, done: done}
+        }()
+    }
+    launch()
+    timer := time.NewTimer(policy.delay())
+    defer timer.Stop()
+    for inflight > 0 {
+        select {
+        case result := <-results:
+            inflight--
+            responses = append(responses, result.responses...)
+            if result.done {
+                // 取消其余进行中的请求
+                return responses
+            }
+            if next < len(serviceUrls) {
+                launch()
+                if !timer.Stop() {
+                    select {
+                    case <-timer.C:
+                    default:
+                    }
+                }
+                timer.Reset(policy.delay())
+            }
+        case <-timer.C:
+            if next < len(serviceUrls) && inflight < policy.maxRequests() {
+                client.GetLogger().Tracef("HttpClient.doHedgedRequest, hedge request >>> serviceUrl: %s, uri: %s", serviceUrls[next], uri)
+                launch()
+            }
+            timer.Reset(policy.delay())
+        }
+    }
+    return responses
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestHttpClient_HedgePolicy(t *testing.T) {
+    ast := assert.New(t)
+    slow, fast := newTestEurekaServer(), newTestEurekaServer()
+    defer slow.Close()
+    defer fast.Close()
+    var cancelled int32
+    slow.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        select {
+        case <-r.Context().Done():
+            atomic.AddInt32(&cancelled, 1)
+        case <-time.After(2 * time.Second):
+            w.WriteHeader(http.StatusServiceUnavailable)
+        }
+        return true
+    })
+    instance := newTestServerInstance(ast, "order", "order-1", "http://127.0.0.1:8080")
+    slow.Put(instance)
+    fast.Put(instance)
+    client := &HttpClient{HedgePolicy: &HedgePolicy{Delay: 50 * time.Millisecond}}
+    serviceUrl := slow.URL + "," + fast.URL
+
+    // 首个服务地址响应慢时向下一个服务地址发送对冲请求, 采用最先成功的响应
+    start := time.Now()
+    appsResponse := client.SimpleQueryApps(serviceUrl)
+    ast.Nilf(appsResponse.Error, "%v", appsResponse.Error)
+    ast.Less(time.Since(start), time.Second)
+    ast.Len(appsResponse.Apps, 1)
+    ast.Equal(fast.URL, appsResponse.Response.Request.ServiceUrl)
+    ast.Len(appsResponse.Response.Responses, 1)
+
+    instancesResponse := client.SimpleQueryApp(serviceUrl, "ORDER")
+    ast.Nilf(instancesResponse.Error, "%v", instancesResponse.Error)
+    ast.Len(instancesResponse.Instances, 1)
+    appsResponse = client.SimpleQueryVipApps(serviceUrl, instance.VipAddress)
+    ast.Nilf(appsResponse.Error, "%v", appsResponse.Error)
+    ast.Len(appsResponse.Apps, 1)
+    instanceResponse := client.SimpleQueryInstance(serviceUrl, "order-1")
+    ast.Nilf(instanceResponse.Error, "%v", instanceResponse.Error)
+    ast.Equal("order-1", instanceResponse.Instance.InstanceId)
+
+    // 落后的请求被取消
+    timeout := time.After(time.Second)
+    for atomic.LoadInt32(&cancelled) < 4 {
+        select {
+        case <-timeout:
+            ast.FailNow("timed out waiting for cancellation")
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+
+    // 首个服务地址快速失败时立即请求下一个服务地址
+    slow.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusServiceUnavailable)
+        return true
+    })
+    client.HedgePolicy.Delay = 5 * time.Second
+    start = time.Now()
+    appsResponse = client.SimpleQueryApps(serviceUrl)
+    ast.Nilf(appsResponse.Error, "%v", appsResponse.Error)
+    ast.Less(time.Since(start), time.Second)
+    ast.Len(appsResponse.Response.Responses, 2)
+
+    // 所有服务地址均失败
+    closedUrl := newTestClosedUrl(ast)
+    appsResponse = client.SimpleQueryApps(slow.URL + "," + closedUrl)
+    ast.NotNil(appsResponse.Error)
+    ast.Len(appsResponse.Response.Responses, 2)
+
+    // 非查询请求不对冲
+    var heartbeats int32
+    slow.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        time.Sleep(200 * time.Millisecond)
+        atomic.AddInt32(&heartbeats, 1)
+        return false
+    })
+    client.HedgePolicy.Delay = 10 * time.Millisecond
+    ast.Nil(client.SimpleHeartbeat(serviceUrl, "order", "order-1").Error)
+    ast.Equal(int32(1), atomic.LoadInt32(&heartbeats))
+    for _, request := range fast.Requests() {
+        ast.NotEqual(http.MethodPut+" /apps/order/order-1", request)
+    }
+
+    // 未显式开启对冲的查询请求(如: QueryServerStatus, QueryAppsDelta)不对冲
+    ast.Nil(client.QueryServerStatus(&meta.EurekaServer{ServiceUrl: serviceUrl}).Error)
+    ast.Nil(client.SimpleQueryAppsDelta(serviceUrl).Error)
+    ast.Equal(int32(3), atomic.LoadInt32(&heartbeats))
+    for _, request := range fast.Requests() {
+        ast.NotEqual(http.MethodGet+" /status", request)
+        ast.NotEqual(http.MethodGet+" /apps/delta", request)
+    }
+}
+
+func TestHttpClient_HedgePolicyWithCircuitBreaker(t *testing.T) {
+    ast := assert.New(t)
+    slow, fast := newTestEurekaServer(), newTestEurekaServer()
+    defer slow.Close()
+    defer fast.Close()
+    slow.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        select {
+        case <-r.Context().Done():
+        case <-time.After(2 * time.Second):
+        }
+        return true
+    })
+    fast.Put(&meta.InstanceInfo{AppName: "order", InstanceId: "order-1"})
+    breaker := NewCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 1})
+    client := &HttpClient{HedgePolicy: &HedgePolicy{Delay: 20 * time.Millisecond}, CircuitBreaker: breaker}
+    for i := 0; i < 3; i++ {
+        response := client.SimpleQueryApps(slow.URL + "," + fast.URL)
+        ast.Nilf(response.Error, "%v", response.Error)
+    }
+    // 被取消的请求不计入熔断统计
+    ast.Equal(BreakerClosed, breaker.State(slow.URL))
+}
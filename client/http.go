@@ -1,12 +1,15 @@
 package client
 
 import (
+    "compress/gzip"
+    "context"
     "encoding/json"
     "errors"
     "fmt"
     "github.com/google/uuid"
     "github.com/jiashunx/eureka-client-go/log"
     "github.com/jiashunx/eureka-client-go/meta"
+    "io"
     "io/ioutil"
     "math"
     "net/http"
@@ -18,6 +21,14 @@ import (
 // HttpClient eureka客户端与服务端进行http通讯的客户端模型
 type HttpClient struct {
     Logger log.Logger
+    // 重试策略, 为nil时每个eureka server服务地址仅请求一次
+    RetryPolicy *RetryPolicy
+    // eureka server服务地址熔断器, 为nil时不熔断
+    CircuitBreaker *CircuitBreaker
+    // 查询请求对冲策略, 为nil时依次请求各eureka server服务地址
+    HedgePolicy *HedgePolicy
+    // 是否禁用gzip压缩(默认请求eureka server压缩响应内容)
+    DisableCompression bool
 }
 
 // GetLogger 获取客户端日志对象
@@ -28,8 +39,23 @@ func (client *HttpClient) GetLogger() log.Logger {
     return client.Logger
 }
 
+// requestOptions 与eureka server通讯附加选项
+type requestOptions struct {
+    // 附加请求头, 如: If-None-Match
+    header http.Header
+    // 是否允许对冲请求(配置 HedgePolicy 时), 仅服务发现查询请求开启
+    hedge bool
+    // 响应码符合预期时直接从响应流解析响应内容(不保留 EurekaResponse.Body), 如: 服务列表
+    decode func(reader io.Reader) (interface{}, error)
+}
+
 // doRequest 与eureka server通讯处理
-func (client *HttpClient) doRequest(expect int, server *meta.EurekaServer, method string, uri string, payload []byte) (ret *EurekaResponse) {
+func (client *HttpClient) doRequest(expect int, server *meta.EurekaServer, method string, uri string, payload []byte) *EurekaResponse {
+    return client.doRequestWithOptions(expect, server, method, uri, payload, nil)
+}
+
+// doRequestWithOptions 与eureka server通讯处理(附加通讯选项, 如: 请求头、对冲请求)
+func (client *HttpClient) doRequestWithOptions(expect int, server *meta.EurekaServer, method string, uri string, payload []byte, options *requestOptions) (ret *EurekaResponse) {
     var responses = make([]*EurekaResponse, 0)
     defer func() {
         if rc := recover(); rc != nil {
@@ -43,6 +69,10 @@ func (client *HttpClient) doRequest(expect int, server *meta.EurekaServer, metho
         }
         for _, r := range responses {
             r.Responses = responses
+            var httpError *HttpError
+            if errors.As(r.Error, &httpError) {
+                httpError.Responses = responses
+            }
         }
         ret = responses[len(responses)-1]
         if ret.Error != nil {
@@ -56,88 +86,25 @@ func (client *HttpClient) doRequest(expect int, server *meta.EurekaServer, metho
         panic(errors.New("EurekaServer is nil"))
     }
     client.GetLogger().Tracef("HttpClient.doRequest, PARAMS >>> expect: %d, method: %s, uri: %s, server: %#v", expect, method, uri, server)
-    // 遍历eureka server服务地址，循环发请求直至成功
-    for idx, serviceUrl := range strings.Split(server.ServiceUrl, ",") {
-        serviceUrl = strings.TrimSpace(serviceUrl)
-        if serviceUrl == "" {
-            continue
-        }
-        request := &EurekaRequest{
-            ServiceUrl:   serviceUrl,
-            AuthUsername: "",
-            AuthPassword: "",
-            Method:       method,
-            RequestUrl:   "",
-            RequestUri:   uri,
-            Body:         "",
-        }
-        response := &EurekaResponse{
-            UUID:    strings.ReplaceAll(uuid.New().String(), "-", ""),
-            Request: request,
-        }
-        if payload != nil {
-            request.Body = string(payload)
-        }
-        URL, err := url.Parse(serviceUrl)
-        if err != nil {
-            response.Error = err
-            responses = append(responses, response)
-            client.GetLogger().Tracef("HttpClient.doRequest, failed to parse serviceUrl >> idx: %d, serviceUrl: %s, error: %v", idx, serviceUrl, err)
-            continue
-        }
-        if URL.User != nil && URL.User.String() != "" {
-            password, _ := URL.User.Password()
-            request.AuthUsername = URL.User.Username()
-            request.AuthPassword = password
-        } else if server.Username != "" {
-            request.AuthUsername = server.Username
-            request.AuthPassword = server.Password
-        }
-        request.RequestUrl = URL.Scheme + "://" + URL.Hostname() + ":" + URL.Port() + URL.Path + strings.TrimSpace(uri)
-        if URL.Port() == "" {
-            request.RequestUrl = URL.Scheme + "://" + URL.Hostname() + URL.Path + strings.TrimSpace(uri)
-        }
-        client.GetLogger().Tracef("HttpClient.doRequest, create request object >>> idx: %d, method: %s, requestUrl: %s, body: %s", idx, method, request.RequestUrl, request.Body)
-        httpRequest, err := http.NewRequest(request.Method, request.RequestUrl, strings.NewReader(request.Body))
-        response.HttpRequest = httpRequest
-        response.Error = err
-        if response.Error != nil {
-            responses = append(responses, response)
-            client.GetLogger().Tracef("HttpClient.doRequest, failed to create request object >>> idx: %d, error: %v", idx, err)
-            continue
-        }
-        if request.AuthUsername != "" {
-            httpRequest.SetBasicAuth(request.AuthUsername, request.AuthPassword)
-        }
-        httpRequest.Header.Set("Accept", "application/json")
-        if request.Body != "" {
-            httpRequest.Header.Set("Content-Type", "application/json")
-        }
-        httpClient := http.DefaultClient
-        if server.ReadTimeoutSeconds > 0 || server.ConnectTimeoutSeconds > 0 {
-            seconds := time.Duration(int64(math.Max(float64(server.ReadTimeoutSeconds), float64(server.ConnectTimeoutSeconds))))
-            httpClient = &http.Client{Timeout: seconds * time.Second}
-        }
-        httpResponse, err := httpClient.Do(httpRequest)
-        response.HttpResponse = httpResponse
-        response.Error = err
-        responses = append(responses, response)
-        if response.Error == nil {
-            var body []byte
-            body, response.Error = ioutil.ReadAll(httpResponse.Body)
-            if response.Error == nil {
-                response.Body = string(body)
-            }
-            _ = httpResponse.Body.Close()
+    serviceUrls := make([]string, 0)
+    for _, serviceUrl := range strings.Split(server.ServiceUrl, ",") {
+        if serviceUrl = strings.TrimSpace(serviceUrl); serviceUrl != "" {
+            serviceUrls = append(serviceUrls, serviceUrl)
         }
-        if response.Error == nil {
-            if httpResponse.StatusCode == expect {
+    }
+    if options == nil {
+        options = &requestOptions{}
+    }
+    if client.HedgePolicy != nil && options.hedge && len(serviceUrls) > 1 {
+        responses = client.doHedgedRequest(expect, server, method, uri, payload, options, serviceUrls)
+    } else {
+        // 遍历eureka server服务地址，循环发请求直至成功（配置重试策略时同一服务地址按策略重试）
+        for idx, serviceUrl := range serviceUrls {
+            rs, done := client.doServiceUrl(context.Background(), expect, server, method, uri, payload, options, serviceUrl, idx)
+            responses = append(responses, rs...)
+            if done {
                 break
             }
-            response.Error = errors.New(fmt.Sprintf("the http response code is incorrect, expect: %d, actual: %d", expect, response.HttpResponse.StatusCode))
-        }
-        if response.Error != nil {
-            client.GetLogger().Tracef("HttpClient.doRequest, request failed >>> idx: %d, error: %v", idx, err)
         }
     }
     if len(responses) == 0 {
@@ -146,6 +113,215 @@ func (client *HttpClient) doRequest(expect int, server *meta.EurekaServer, metho
     return nil
 }
 
+// doServiceUrl 向指定eureka server服务地址发送请求(按重试策略重试), 返回所有通讯响应及是否结束本次通讯(成功或明确的客户端错误)
+func (client *HttpClient) doServiceUrl(ctx context.Context, expect int, server *meta.EurekaServer, method string, uri string, payload []byte, options *requestOptions, serviceUrl string, idx int) ([]*EurekaResponse, bool) {
+    responses := make([]*EurekaResponse, 0)
+    for attempt := 1; ; attempt++ {
+        if client.CircuitBreaker != nil && !client.CircuitBreaker.allow(serviceUrl) {
+            request := &EurekaRequest{ServiceUrl: serviceUrl, Method: method, RequestUri: uri}
+            responses = append(responses, &EurekaResponse{
+                UUID:    strings.ReplaceAll(uuid.New().String(), "-", ""),
+                Request: request,
+                Error:   newHttpError(ErrCircuitOpen, request, 0, errors.New(fmt.Sprintf("the circuit breaker of %s is open", serviceUrl))),
+                Attempt: attempt,
+            })
+            client.GetLogger().Tracef("HttpClient.doRequest, circuit breaker is open >>> idx: %d, serviceUrl: %s", idx, serviceUrl)
+            return responses, false
+        }
+        response := client.doAttempt(ctx, expect, server, method, uri, payload, options, serviceUrl, idx)
+        response.Attempt = attempt
+        responses = append(responses, response)
+        // 请求被取消(如: 对冲请求已有其他服务地址成功响应)时不计入熔断统计
+        if ctx.Err() != nil {
+            if client.CircuitBreaker != nil {
+                client.CircuitBreaker.release(serviceUrl)
+            }
+            return responses, false
+        }
+        if client.CircuitBreaker != nil {
+            if errors.Is(response.Error, ErrServerUnavailable) {
+                client.CircuitBreaker.report(serviceUrl, response.Error)
+            } else {
+                client.CircuitBreaker.report(serviceUrl, nil)
+            }
+        }
+        if response.Error == nil {
+            return responses, true
+        }
+        if client.RetryPolicy == nil {
+            return responses, false
+        }
+        if !client.RetryPolicy.retryable(expect, response) {
+            client.GetLogger().Tracef("HttpClient.doRequest, non-retryable error >>> idx: %d, attempt: %d, error: %v", idx, attempt, response.Error)
+            return responses, isClientError(response)
+        }
+        if attempt >= client.RetryPolicy.maxAttempts() {
+            return responses, false
+        }
+        backoff := client.RetryPolicy.backoff(attempt)
+        client.GetLogger().Tracef("HttpClient.doRequest, retry after %v >>> idx: %d, attempt: %d, error: %v", backoff, idx, attempt, response.Error)
+        timer := time.NewTimer(backoff)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return responses, false
+        case <-timer.C:
+        }
+    }
+}
+
+// doAttempt 向指定eureka server服务地址发送一次请求
+func (client *HttpClient) doAttempt(ctx context.Context, expect int, server *meta.EurekaServer, method string, uri string, payload []byte, options *requestOptions, serviceUrl string, idx int) *EurekaResponse {
+    request := &EurekaRequest{
+        ServiceUrl:   serviceUrl,
+        AuthUsername: "",
+        AuthPassword: "",
+        Method:       method,
+        RequestUrl:   "",
+        RequestUri:   uri,
+        Body:         "",
+    }
+    response := &EurekaResponse{
+        UUID:    strings.ReplaceAll(uuid.New().String(), "-", ""),
+        Request: request,
+    }
+    if payload != nil {
+        request.Body = string(payload)
+    }
+    URL, err := url.Parse(serviceUrl)
+    if err != nil {
+        response.Error = newHttpError(nil, request, 0, err)
+        client.GetLogger().Tracef("HttpClient.doRequest, failed to parse serviceUrl >> idx: %d, serviceUrl: %s, error: %v", idx, serviceUrl, err)
+        return response
+    }
+    if URL.User != nil && URL.User.String() != "" {
+        password, _ := URL.User.Password()
+        request.AuthUsername = URL.User.Username()
+        request.AuthPassword = password
+    } else if server.Username != "" {
+        request.AuthUsername = server.Username
+        request.AuthPassword = server.Password
+    }
+    request.RequestUrl = URL.Scheme + "://" + URL.Hostname() + ":" + URL.Port() + URL.Path + strings.TrimSpace(uri)
+    if URL.Port() == "" {
+        request.RequestUrl = URL.Scheme + "://" + URL.Hostname() + URL.Path + strings.TrimSpace(uri)
+    }
+    client.GetLogger().Tracef("HttpClient.doRequest, create request object >>> idx: %d, method: %s, requestUrl: %s, body: %s", idx, method, request.RequestUrl, request.Body)
+    if client.RetryPolicy != nil && client.RetryPolicy.PerAttemptTimeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, client.RetryPolicy.PerAttemptTimeout)
+        defer cancel()
+    }
+    httpRequest, err := http.NewRequestWithContext(ctx, request.Method, request.RequestUrl, strings.NewReader(request.Body))
+    response.HttpRequest = httpRequest
+    if err != nil {
+        response.Error = newHttpError(nil, request, 0, err)
+        client.GetLogger().Tracef("HttpClient.doRequest, failed to create request object >>> idx: %d, error: %v", idx, err)
+        return response
+    }
+    if request.AuthUsername != "" {
+        httpRequest.SetBasicAuth(request.AuthUsername, request.AuthPassword)
+    }
+    httpRequest.Header.Set("Accept", "application/json")
+    if request.Body != "" {
+        httpRequest.Header.Set("Content-Type", "application/json")
+    }
+    // 显式声明Accept-Encoding(未声明时http.Transport自动请求gzip并透明解压), 以便统计实际传输字节数
+    if client.DisableCompression {
+        httpRequest.Header.Set("Accept-Encoding", "identity")
+    } else {
+        httpRequest.Header.Set("Accept-Encoding", "gzip")
+    }
+    for key, values := range options.header {
+        for _, value := range values {
+            httpRequest.Header.Add(key, value)
+        }
+    }
+    httpClient := http.DefaultClient
+    if server.ReadTimeoutSeconds > 0 || server.ConnectTimeoutSeconds > 0 {
+        seconds := time.Duration(int64(math.Max(float64(server.ReadTimeoutSeconds), float64(server.ConnectTimeoutSeconds))))
+        httpClient = &http.Client{Timeout: seconds * time.Second}
+    }
+    httpResponse, err := httpClient.Do(httpRequest)
+    response.HttpResponse = httpResponse
+    if err != nil {
+        response.Error = newHttpError(ErrServerUnavailable, request, 0, err)
+    }
+    if response.Error == nil {
+        var decode func(reader io.Reader) (interface{}, error)
+        if httpResponse.StatusCode == expect {
+            decode = options.decode
+        }
+        body, err := client.readBody(response, httpResponse, decode)
+        if err == nil {
+            response.Body = string(body)
+        } else {
+            response.Error = newHttpError(ErrServerUnavailable, request, httpResponse.StatusCode, err)
+        }
+        _ = httpResponse.Body.Close()
+    }
+    // 条件请求返回304时视为成功
+    notModified := httpResponse != nil && httpResponse.StatusCode == http.StatusNotModified && options.header.Get("If-None-Match") != ""
+    if response.Error == nil && httpResponse.StatusCode != expect && !notModified {
+        statusCode := httpResponse.StatusCode
+        err = errors.New(fmt.Sprintf("the http response code is incorrect, expect: %d, actual: %d", expect, statusCode))
+        response.Error = newHttpError(classifyStatusCode(statusCode), request, statusCode, err)
+    }
+    if response.Error != nil {
+        client.GetLogger().Tracef("HttpClient.doRequest, request failed >>> idx: %d, error: %v", idx, response.Error)
+    }
+    return response
+}
+
+// readBody 读取响应内容(按需gzip解压), 记录实际传输字节数; decode不为nil时直接从响应流解析响应内容, 不读取至内存
+func (client *HttpClient) readBody(response *EurekaResponse, httpResponse *http.Response, decode func(reader io.Reader) (interface{}, error)) ([]byte, error) {
+    counter := &countingReader{reader: httpResponse.Body}
+    defer func() {
+        response.BytesTransferred = counter.count
+    }()
+    var reader io.Reader = counter
+    if strings.EqualFold(httpResponse.Header.Get("Content-Encoding"), "gzip") {
+        response.Compressed = true
+        gzipReader, err := gzip.NewReader(counter)
+        if err != nil && err != io.EOF {
+            return nil, err
+        }
+        if err == io.EOF {
+            reader = strings.NewReader("")
+        } else {
+            defer func() {
+                _ = gzipReader.Close()
+            }()
+            reader = gzipReader
+        }
+    }
+    if decode == nil {
+        return ioutil.ReadAll(reader)
+    }
+    response.decoded, response.decodeErr = decode(reader)
+    // 读取剩余内容(以便复用连接), 读取响应失败时视为通讯失败
+    _, _ = io.Copy(ioutil.Discard, reader)
+    return []byte{}, counter.err
+}
+
+// countingReader 统计读取字节数
+type countingReader struct {
+    reader io.Reader
+    count  int64
+    // 读取失败错误(不含io.EOF)
+    err error
+}
+
+// Read 读取并累计字节数
+func (counter *countingReader) Read(p []byte) (int, error) {
+    n, err := counter.reader.Read(p)
+    counter.count += int64(n)
+    if err != nil && err != io.EOF && counter.err == nil {
+        counter.err = err
+    }
+    return n, err
+}
+
 // Register 注册新服务
 func (client *HttpClient) Register(server *meta.EurekaServer, instance *meta.InstanceInfo) (ret *CommonResponse) {
     defer func() {
@@ -207,7 +383,7 @@ func (client *HttpClient) SimpleHeartbeat(serviceUrl, appName, instanceId string
 
 // QueryApps 查询所有服务列表
 func (client *HttpClient) QueryApps(server *meta.EurekaServer) *AppsResponse {
-    return client.getApps(server, "/apps")
+    return client.getApps(server, "/apps", true)
 }
 
 // SimpleQueryApps 查询所有服务列表
@@ -215,9 +391,23 @@ func (client *HttpClient) SimpleQueryApps(serviceUrl string) *AppsResponse {
     return client.QueryApps(&meta.EurekaServer{ServiceUrl: serviceUrl})
 }
 
+// QueryAppsConditional 条件查询所有服务列表: 携带上次查询结果的ETag(If-None-Match), eureka server返回304(不解析响应内容)
+// 或服务列表未变化(apps__hashcode及各服务实例lastDirtyTimestamp一致)时复用上次查询结果的服务列表, 并标记 AppsResponse.CacheHit
+func (client *HttpClient) QueryAppsConditional(server *meta.EurekaServer, cached *AppsResponse) *AppsResponse {
+    if cached != nil && cached.Error != nil {
+        cached = nil
+    }
+    return client.getAppsConditional(server, "/apps", cached, true)
+}
+
+// SimpleQueryAppsConditional 条件查询所有服务列表
+func (client *HttpClient) SimpleQueryAppsConditional(serviceUrl string, cached *AppsResponse) *AppsResponse {
+    return client.QueryAppsConditional(&meta.EurekaServer{ServiceUrl: serviceUrl}, cached)
+}
+
 // QueryApp 查询指定appName的服务实例列表
 func (client *HttpClient) QueryApp(server *meta.EurekaServer, appName string) *InstancesResponse {
-    return client.getInstances(server, fmt.Sprintf("/apps/%s", appName))
+    return client.getInstances(server, fmt.Sprintf("/apps/%s", appName), true)
 }
 
 // SimpleQueryApp 查询指定appName的服务实例列表
@@ -227,7 +417,7 @@ func (client *HttpClient) SimpleQueryApp(serviceUrl, appName string) *InstancesR
 
 // QueryAppInstance 查询指定appName&InstanceId服务实例
 func (client *HttpClient) QueryAppInstance(server *meta.EurekaServer, appName, instanceId string) *InstanceResponse {
-    return client.getInstance(server, fmt.Sprintf("/apps/%s/%s", appName, instanceId))
+    return client.getInstance(server, fmt.Sprintf("/apps/%s/%s", appName, instanceId), false)
 }
 
 // SimpleQueryAppInstance 查询指定appName&InstanceId服务实例
@@ -237,7 +427,7 @@ func (client *HttpClient) SimpleQueryAppInstance(serviceUrl, appName, instanceId
 
 // QueryInstance 查询指定InstanceId服务实例
 func (client *HttpClient) QueryInstance(server *meta.EurekaServer, instanceId string) *InstanceResponse {
-    return client.getInstance(server, fmt.Sprintf("/instances/%s", instanceId))
+    return client.getInstance(server, fmt.Sprintf("/instances/%s", instanceId), true)
 }
 
 // SimpleQueryInstance 查询指定InstanceId服务实例
@@ -256,15 +446,19 @@ func (client *HttpClient) SimpleChangeStatus(serviceUrl, appName, instanceId str
     return client.ChangeStatus(&meta.EurekaServer{ServiceUrl: serviceUrl}, appName, instanceId, status)
 }
 
-// ModifyMetadata 变更元数据
+// ModifyMetadata 变更元数据(合并至服务实例已有元数据, eureka server不支持通过该接口删除key)
 func (client *HttpClient) ModifyMetadata(server *meta.EurekaServer, appName, instanceId string, metadata map[string]string) *CommonResponse {
-    requestUrl := fmt.Sprintf("/apps/%s/%s/metadata?", appName, instanceId)
-    if metadata != nil {
-        for k, v := range metadata {
-            requestUrl = requestUrl + k + "=" + v + "&"
+    values := url.Values{}
+    for key, value := range metadata {
+        if err := meta.ValidateMetadataKey(key); err != nil {
+            return &CommonResponse{Error: err}
         }
+        values.Set(key, value)
+    }
+    requestUrl := fmt.Sprintf("/apps/%s/%s/metadata", appName, instanceId)
+    if len(values) > 0 {
+        requestUrl = requestUrl + "?" + values.Encode()
     }
-    requestUrl = requestUrl[0:(len(requestUrl) - 2)]
     return client.commonHttp(200, server, "PUT", requestUrl, nil)
 }
 
@@ -275,7 +469,7 @@ func (client *HttpClient) SimpleModifyMetadata(serviceUrl, appName, instanceId s
 
 // QueryVipApps 查询指定虚拟主机名下的服务列表
 func (client *HttpClient) QueryVipApps(server *meta.EurekaServer, vipAddress string) *AppsResponse {
-    return client.getApps(server, fmt.Sprintf("/vips/%s", vipAddress))
+    return client.getApps(server, fmt.Sprintf("/vips/%s", vipAddress), true)
 }
 
 // SimpleQueryVipApps 查询指定虚拟主机名下的服务列表
@@ -285,7 +479,7 @@ func (client *HttpClient) SimpleQueryVipApps(serviceUrl, vipAddress string) *App
 
 // QuerySvipApps 查询指定安全虚拟主机名下的服务列表
 func (client *HttpClient) QuerySvipApps(server *meta.EurekaServer, svipAddress string) *AppsResponse {
-    return client.getApps(server, fmt.Sprintf("/svips/%s", svipAddress))
+    return client.getApps(server, fmt.Sprintf("/svips/%s", svipAddress), false)
 }
 
 // SimpleQuerySvipApps 查询指定安全虚拟主机名下的服务列表
@@ -293,6 +487,127 @@ func (client *HttpClient) SimpleQuerySvipApps(serviceUrl, svipAddress string) *A
     return client.QuerySvipApps(&meta.EurekaServer{ServiceUrl: serviceUrl}, svipAddress)
 }
 
+// DeleteStatusOverride 删除服务实例覆盖状态, status不为空时同时变更服务实例状态
+func (client *HttpClient) DeleteStatusOverride(server *meta.EurekaServer, appName, instanceId string, status meta.InstanceStatus) *CommonResponse {
+    requestUrl := fmt.Sprintf("/apps/%s/%s/status", appName, instanceId)
+    if status != "" {
+        requestUrl = requestUrl + "?value=" + url.QueryEscape(string(status))
+    }
+    return client.commonHttp(200, server, "DELETE", requestUrl, nil)
+}
+
+// SimpleDeleteStatusOverride 删除服务实例覆盖状态, status不为空时同时变更服务实例状态
+func (client *HttpClient) SimpleDeleteStatusOverride(serviceUrl, appName, instanceId string, status meta.InstanceStatus) *CommonResponse {
+    return client.DeleteStatusOverride(&meta.EurekaServer{ServiceUrl: serviceUrl}, appName, instanceId, status)
+}
+
+// QueryAppsDelta 查询增量服务列表(服务实例 ActionType 标识新增、变更或删除)
+func (client *HttpClient) QueryAppsDelta(server *meta.EurekaServer) *AppsResponse {
+    return client.getApps(server, "/apps/delta", false)
+}
+
+// SimpleQueryAppsDelta 查询增量服务列表(服务实例 ActionType 标识新增、变更或删除)
+func (client *HttpClient) SimpleQueryAppsDelta(serviceUrl string) *AppsResponse {
+    return client.QueryAppsDelta(&meta.EurekaServer{ServiceUrl: serviceUrl})
+}
+
+// QueryAppsByRegions 查询所有服务列表(包含指定远程region的服务)
+func (client *HttpClient) QueryAppsByRegions(server *meta.EurekaServer, regions []string) *AppsResponse {
+    escaped := make([]string, 0, len(regions))
+    for _, region := range regions {
+        if region = strings.TrimSpace(region); region != "" {
+            escaped = append(escaped, url.QueryEscape(region))
+        }
+    }
+    if len(escaped) == 0 {
+        return client.QueryApps(server)
+    }
+    return client.getApps(server, "/apps?regions="+strings.Join(escaped, ","), false)
+}
+
+// SimpleQueryAppsByRegions 查询所有服务列表(包含指定远程region的服务)
+func (client *HttpClient) SimpleQueryAppsByRegions(serviceUrl string, regions []string) *AppsResponse {
+    return client.QueryAppsByRegions(&meta.EurekaServer{ServiceUrl: serviceUrl}, regions)
+}
+
+// ChangeAsgStatus 变更AWS ASG状态
+func (client *HttpClient) ChangeAsgStatus(server *meta.EurekaServer, asgName string, status meta.AsgStatus) *CommonResponse {
+    requestUrl := fmt.Sprintf("/asg/%s/status?value=%s", asgName, url.QueryEscape(string(status)))
+    return client.commonHttp(200, server, "PUT", requestUrl, nil)
+}
+
+// SimpleChangeAsgStatus 变更AWS ASG状态
+func (client *HttpClient) SimpleChangeAsgStatus(serviceUrl, asgName string, status meta.AsgStatus) *CommonResponse {
+    return client.ChangeAsgStatus(&meta.EurekaServer{ServiceUrl: serviceUrl}, asgName, status)
+}
+
+// BatchReplicate eureka server节点间批量复制服务实例操作
+func (client *HttpClient) BatchReplicate(server *meta.EurekaServer, instances []*meta.ReplicationInstance) *ReplicationResponse {
+    ret := &ReplicationResponse{Results: make([]*meta.ReplicationInstanceResponse, 0)}
+    if instances == nil {
+        instances = make([]*meta.ReplicationInstance, 0)
+    }
+    payload, err := json.Marshal(map[string][]*meta.ReplicationInstance{"replicationList": instances})
+    if err != nil {
+        ret.Error = err
+        return ret
+    }
+    response := client.requestJson("BatchReplicate", 200, server, "POST", "/peerreplication/batch", payload, func(data []byte) error {
+        body := make(map[string][]*meta.ReplicationInstanceResponse)
+        if err := json.Unmarshal(data, &body); err != nil {
+            return err
+        }
+        if body["responseList"] == nil {
+            return errors.New("the query yielded no results: 'responseList'")
+        }
+        ret.Results = body["responseList"]
+        return nil
+    })
+    ret.Response, ret.StatusCode, ret.Error = response.Response, response.StatusCode, response.Error
+    return ret
+}
+
+// SimpleBatchReplicate eureka server节点间批量复制服务实例操作
+func (client *HttpClient) SimpleBatchReplicate(serviceUrl string, instances []*meta.ReplicationInstance) *ReplicationResponse {
+    return client.BatchReplicate(&meta.EurekaServer{ServiceUrl: serviceUrl}, instances)
+}
+
+// QueryServerStatus 查询eureka server状态信息
+func (client *HttpClient) QueryServerStatus(server *meta.EurekaServer) *ServerStatusResponse {
+    ret := &ServerStatusResponse{}
+    response := client.requestJson("QueryServerStatus", 200, server, "GET", "/status", nil, func(data []byte) (err error) {
+        ret.Status, err = meta.ParseServerStatus(data)
+        return err
+    })
+    ret.Response, ret.StatusCode, ret.Error = response.Response, response.StatusCode, response.Error
+    return ret
+}
+
+// SimpleQueryServerStatus 查询eureka server状态信息
+func (client *HttpClient) SimpleQueryServerStatus(serviceUrl string) *ServerStatusResponse {
+    return client.QueryServerStatus(&meta.EurekaServer{ServiceUrl: serviceUrl})
+}
+
+// QueryStatusOverrides 查询eureka server记录的服务实例覆盖状态(/serverinfo/statusoverrides)
+func (client *HttpClient) QueryStatusOverrides(server *meta.EurekaServer) *StatusOverridesResponse {
+    ret := &StatusOverridesResponse{Overrides: make(map[string]meta.InstanceStatus)}
+    response := client.requestJson("QueryStatusOverrides", 200, server, "GET", "/serverinfo/statusoverrides", nil, func(data []byte) error {
+        overrides := make(map[string]meta.InstanceStatus)
+        if err := json.Unmarshal(data, &overrides); err != nil {
+            return err
+        }
+        ret.Overrides = overrides
+        return nil
+    })
+    ret.Response, ret.StatusCode, ret.Error = response.Response, response.StatusCode, response.Error
+    return ret
+}
+
+// SimpleQueryStatusOverrides 查询eureka server记录的服务实例覆盖状态(/serverinfo/statusoverrides)
+func (client *HttpClient) SimpleQueryStatusOverrides(serviceUrl string) *StatusOverridesResponse {
+    return client.QueryStatusOverrides(&meta.EurekaServer{ServiceUrl: serviceUrl})
+}
+
 // commonHttp 与eureka server通讯公共方法
 func (client *HttpClient) commonHttp(expect int, server *meta.EurekaServer, method string, url string, payload []byte) *CommonResponse {
     ret := &CommonResponse{}
@@ -306,69 +621,136 @@ func (client *HttpClient) commonHttp(expect int, server *meta.EurekaServer, meth
     return ret
 }
 
-// getApps 查询服务列表
-func (client *HttpClient) getApps(server *meta.EurekaServer, uri string) (ret *AppsResponse) {
+// requestJson 与eureka server通讯并解析json响应内容
+func (client *HttpClient) requestJson(name string, expect int, server *meta.EurekaServer, method string, uri string, payload []byte, parse func(data []byte) error) (ret *CommonResponse) {
+    ret = &CommonResponse{}
+    defer func() {
+        if rc := recover(); rc != nil {
+            ret.Error = errors.New(fmt.Sprintf("HttpClient.%s, recover error: %v", name, rc))
+        }
+        // 通讯成功但响应内容解析失败
+        if ret.Error != nil && ret.Response != nil && ret.Response.Error == nil {
+            ret.Error = newDecodeError(ret.Response, ret.Error)
+        }
+        if ret.Error != nil {
+            client.GetLogger().Tracef("HttpClient.%s, FAILED >>> error: %v", name, ret.Error)
+        }
+        if ret.Error == nil {
+            client.GetLogger().Tracef("HttpClient.%s, OK", name)
+        }
+    }()
+    client.GetLogger().Tracef("HttpClient.%s, PARAMS >>> server: %v, uri: %s", name, server, uri)
+    ret = client.commonHttp(expect, server, method, uri, payload)
+    if ret.Error != nil {
+        return ret
+    }
+    ret.Error = parse([]byte(ret.Response.Body))
+    return ret
+}
+
+// getApps 查询服务列表, hedge: 是否允许对冲请求
+func (client *HttpClient) getApps(server *meta.EurekaServer, uri string, hedge bool) *AppsResponse {
+    return client.getAppsConditional(server, uri, nil, hedge)
+}
+
+// getAppsConditional 查询服务列表, 响应未变化(304或服务列表hashcode及服务实例一致)时复用上次查询结果
+func (client *HttpClient) getAppsConditional(server *meta.EurekaServer, uri string, cached *AppsResponse, hedge bool) (ret *AppsResponse) {
     ret = &AppsResponse{Apps: make([]*meta.AppInfo, 0)}
     defer func() {
         if rc := recover(); rc != nil {
             ret.Error = errors.New(fmt.Sprintf("HttpClient.getApps, recover error: %v", rc))
         }
+        // 通讯成功但响应内容解析失败
+        if ret.Error != nil && ret.Response != nil && ret.Response.Error == nil {
+            ret.Error = newDecodeError(ret.Response, ret.Error)
+        }
         if ret.Error != nil {
             client.GetLogger().Tracef("HttpClient.getApps, FAILED >>> error: %v", ret.Error)
         }
         if ret.Error == nil {
-            client.GetLogger().Tracef("HttpClient.getApps, OK >>> ret: %v", SummaryApps(ret.Apps))
+            client.GetLogger().Tracef("HttpClient.getApps, OK >>> cacheHit: %v, bytes: %d, ret: %v", ret.CacheHit, ret.BytesTransferred, SummaryApps(ret.Apps))
         }
     }()
     client.GetLogger().Tracef("HttpClient.getApps, PARAMS >>> server: %v, uri: %s", server, uri)
-    ret.Response = client.doRequest(200, server, "GET", uri, nil)
+    options := &requestOptions{hedge: hedge, decode: func(reader io.Reader) (interface{}, error) {
+        return meta.DecodeApplications(reader)
+    }}
+    if cached != nil && cached.ETag != "" {
+        options.header = http.Header{}
+        options.header.Set("If-None-Match", cached.ETag)
+    }
+    ret.Response = client.doRequestWithOptions(200, server, "GET", uri, nil, options)
     if ret.Response.Error != nil {
         ret.Error = ret.Response.Error
     }
+    ret.BytesTransferred = ret.Response.BytesTransferred
     if ret.Response.HttpResponse != nil {
         ret.StatusCode = ret.Response.HttpResponse.StatusCode
+        ret.ETag = ret.Response.HttpResponse.Header.Get("ETag")
     }
     if ret.Error != nil {
         return ret
     }
-    var ii interface{}
-    ret.Error = json.Unmarshal([]byte(ret.Response.Body), &ii)
-    if ret.Error != nil {
+    if cached != nil && ret.StatusCode == http.StatusNotModified {
+        ret.Applications, ret.Apps, ret.CacheHit = cached.Applications, cached.Apps, true
+        if ret.ETag == "" {
+            ret.ETag = cached.ETag
+        }
         return ret
     }
-    ij := ii.(map[string]interface{})["applications"]
-    if ij == nil {
-        ret.Error = errors.New("the query yielded no results: 'applications'")
+    if ret.Response.decodeErr != nil {
+        ret.Error = ret.Response.decodeErr
         return ret
     }
-    ik := ij.(map[string]interface{})["application"]
-    if ik == nil {
-        ret.Error = errors.New("the query yielded no results: 'applications'.'application'")
+    applications, ok := ret.Response.decoded.(*meta.Applications)
+    if !ok || applications == nil {
+        ret.Error = errors.New("the query yielded no results: 'applications'")
         return ret
     }
-    for _, m := range ik.([]interface{}) {
-        var data []byte
-        data, ret.Error = json.Marshal(m)
-        if ret.Error != nil {
-            return ret
+    ret.Applications, ret.Apps = applications, applications.Apps
+    if cached != nil && sameApplications(cached.Applications, applications) {
+        ret.Applications, ret.Apps, ret.CacheHit = cached.Applications, cached.Apps, true
+        if ret.ETag == "" {
+            ret.ETag = cached.ETag
         }
-        var app *meta.AppInfo
-        app, ret.Error = meta.ParseAppInfo(data)
-        if ret.Error != nil {
-            return ret
-        }
-        ret.Apps = append(ret.Apps, app)
     }
     return ret
 }
 
-// getInstances 查询服务实例列表
-func (client *HttpClient) getInstances(server *meta.EurekaServer, uri string) (ret *InstancesResponse) {
+// sameApplications 服务列表是否未变化: apps__hashcode(各状态服务实例数)一致, 且服务实例及其最后变更时间(lastDirtyTimestamp)一致
+func sameApplications(cached *meta.Applications, applications *meta.Applications) bool {
+    if cached == nil || cached.AppsHashcode == "" || cached.AppsHashcode != applications.AppsHashcode {
+        return false
+    }
+    dirtyTimestamps := make(map[string]string)
+    for _, app := range cached.Apps {
+        for _, instance := range app.Instances {
+            dirtyTimestamps[app.Name+"/"+instance.InstanceId] = instance.LastDirtyTimestamp
+        }
+    }
+    count := 0
+    for _, app := range applications.Apps {
+        for _, instance := range app.Instances {
+            count++
+            if timestamp, ok := dirtyTimestamps[app.Name+"/"+instance.InstanceId]; !ok || timestamp != instance.LastDirtyTimestamp {
+                return false
+            }
+        }
+    }
+    return count == len(dirtyTimestamps)
+}
+
+// getInstances 查询服务实例列表, hedge: 是否允许对冲请求
+func (client *HttpClient) getInstances(server *meta.EurekaServer, uri string, hedge bool) (ret *InstancesResponse) {
     ret = &InstancesResponse{Instances: make([]*meta.InstanceInfo, 0)}
     defer func() {
         if rc := recover(); rc != nil {
             ret.Error = errors.New(fmt.Sprintf("HttpClient.getInstances, recover error: %v", rc))
         }
+        // 通讯成功但响应内容解析失败
+        if ret.Error != nil && ret.Response != nil && ret.Response.Error == nil {
+            ret.Error = newDecodeError(ret.Response, ret.Error)
+        }
         if ret.Error != nil {
             client.GetLogger().Tracef("HttpClient.getInstances, FAILED >>> error: %v", ret.Error)
         }
@@ -377,7 +759,7 @@ func (client *HttpClient) getInstances(server *meta.EurekaServer, uri string) (r
         }
     }()
     client.GetLogger().Tracef("HttpClient.getInstances, PARAMS >>> server: %v, uri: %s", server, uri)
-    ret.Response = client.doRequest(200, server, "GET", uri, nil)
+    ret.Response = client.doRequestWithOptions(200, server, "GET", uri, nil, &requestOptions{hedge: hedge})
     if ret.Response.Error != nil {
         ret.Error = ret.Response.Error
     }
@@ -418,13 +800,17 @@ func (client *HttpClient) getInstances(server *meta.EurekaServer, uri string) (r
     return ret
 }
 
-// getInstance 查询服务实例
-func (client *HttpClient) getInstance(server *meta.EurekaServer, uri string) (ret *InstanceResponse) {
+// getInstance 查询服务实例, hedge: 是否允许对冲请求
+func (client *HttpClient) getInstance(server *meta.EurekaServer, uri string, hedge bool) (ret *InstanceResponse) {
     ret = &InstanceResponse{}
     defer func() {
         if rc := recover(); rc != nil {
             ret.Error = errors.New(fmt.Sprintf("HttpClient.getInstance, recover error: %v", rc))
         }
+        // 通讯成功但响应内容解析失败
+        if ret.Error != nil && ret.Response != nil && ret.Response.Error == nil {
+            ret.Error = newDecodeError(ret.Response, ret.Error)
+        }
         if ret.Error != nil {
             client.GetLogger().Tracef("HttpClient.getInstance, FAILED >>> error: %v", ret.Error)
         }
@@ -433,7 +819,7 @@ func (client *HttpClient) getInstance(server *meta.EurekaServer, uri string) (re
         }
     }()
     client.GetLogger().Tracef("HttpClient.getInstance, PARAMS >>> server: %v, uri: %s", server, uri)
-    ret.Response = client.doRequest(200, server, "GET", uri, nil)
+    ret.Response = client.doRequestWithOptions(200, server, "GET", uri, nil, &requestOptions{hedge: hedge})
     if ret.Response.Error != nil {
         ret.Error = ret.Response.Error
     }
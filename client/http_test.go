@@ -1,9 +1,16 @@
 package client
 
 import (
+    "compress/gzip"
+    "errors"
+    "fmt"
     "github.com/jiashunx/eureka-client-go/log"
     "github.com/jiashunx/eureka-client-go/meta"
     "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
     "testing"
     "time"
 )
@@ -121,3 +128,362 @@ func TestHttpClient_UnRegister(t *testing.T) {
     ast.Nilf(response.Error, "%v", response.Error)
     ast.Equal(200, response.StatusCode)
 }
+
+func TestHttpClient_DeleteStatusOverride(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    client := &HttpClient{}
+
+    ast.Nil(client.SimpleChangeStatus(server.URL, "ORDER", "order-1", meta.StatusOutOfService).Error)
+    overrides := client.SimpleQueryStatusOverrides(server.URL)
+    ast.Nilf(overrides.Error, "%v", overrides.Error)
+    ast.Equal(map[string]meta.InstanceStatus{"order-1": meta.StatusOutOfService}, overrides.Overrides)
+
+    response := client.SimpleDeleteStatusOverride(server.URL, "ORDER", "order-1", meta.StatusDown)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(200, response.StatusCode)
+    ast.Equal(meta.StatusDown, server.Instance("ORDER", "order-1").Status)
+    ast.Contains(server.Requests(), "DELETE /apps/ORDER/order-1/status?value=DOWN")
+    overrides = client.SimpleQueryStatusOverrides(server.URL)
+    ast.Nilf(overrides.Error, "%v", overrides.Error)
+    ast.Empty(overrides.Overrides)
+
+    ast.Nil(client.SimpleDeleteStatusOverride(server.URL, "ORDER", "order-1", "").Error)
+    ast.Contains(server.Requests(), "DELETE /apps/ORDER/order-1/status")
+    ast.Equal(meta.StatusUp, server.Instance("ORDER", "order-1").Status)
+    ast.Equal(404, client.SimpleDeleteStatusOverride(server.URL, "ORDER", "missing", "").StatusCode)
+}
+
+func TestHttpClient_QueryAppsDelta(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp, ActionType: meta.Modified})
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusUp, ActionType: meta.Deleted})
+    client := &HttpClient{}
+
+    response := client.SimpleQueryAppsDelta(server.URL)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(200, response.StatusCode)
+    ast.Len(response.Apps, 1)
+    actions := make(map[string]meta.ActionType)
+    for _, instance := range response.Apps[0].Instances {
+        actions[instance.InstanceId] = instance.ActionType
+    }
+    ast.Equal(map[string]meta.ActionType{"order-1": meta.Modified, "order-2": meta.Deleted}, actions)
+    ast.Contains(server.Requests(), "GET /apps/delta")
+}
+
+func TestHttpClient_QueryAppsByRegions(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    client := &HttpClient{}
+
+    response := client.SimpleQueryAppsByRegions(server.URL, []string{"us-east-1", " us-west-2 ", ""})
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Len(response.Apps, 1)
+    ast.Contains(server.Requests(), "GET /apps?regions=us-east-1,us-west-2")
+    response = client.SimpleQueryAppsByRegions(server.URL, nil)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal("GET /apps", server.Requests()[len(server.Requests())-1])
+}
+
+func TestHttpClient_ChangeAsgStatus(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client := &HttpClient{}
+
+    response := client.SimpleChangeAsgStatus(server.URL, "order-asg", meta.AsgDisabled)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(200, response.StatusCode)
+    ast.Equal(meta.AsgDisabled, server.AsgStatus("order-asg"))
+    ast.Nil(client.SimpleChangeAsgStatus(server.URL, "order-asg", meta.AsgEnabled).Error)
+    ast.Equal(meta.AsgEnabled, server.AsgStatus("order-asg"))
+    ast.Equal(400, client.SimpleChangeAsgStatus(server.URL, "order-asg", "BAD").StatusCode)
+}
+
+func TestHttpClient_BatchReplicate(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusUp})
+    client := &HttpClient{}
+
+    response := client.SimpleBatchReplicate(server.URL, []*meta.ReplicationInstance{
+        {AppName: "PAY", Id: "pay-1", Action: meta.ReplicationRegister, InstanceInfo: &meta.InstanceInfo{AppName: "PAY", InstanceId: "pay-1", Status: meta.StatusUp}},
+        {AppName: "ORDER", Id: "order-1", Action: meta.ReplicationHeartbeat, LastDirtyTimestamp: time.Now().UnixMilli()},
+        {AppName: "ORDER", Id: "order-1", Action: meta.ReplicationStatusUpdate, Status: meta.StatusOutOfService},
+        {AppName: "ORDER", Id: "order-2", Action: meta.ReplicationCancel},
+        {AppName: "ORDER", Id: "missing", Action: meta.ReplicationHeartbeat},
+    })
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(200, response.StatusCode)
+    ast.Len(response.Results, 5)
+    ast.Equal(204, response.Results[0].StatusCode)
+    ast.Equal(200, response.Results[1].StatusCode)
+    ast.Equal("order-1", response.Results[1].ResponseEntity.InstanceId)
+    ast.Equal(200, response.Results[2].StatusCode)
+    ast.Equal(200, response.Results[3].StatusCode)
+    ast.Equal(404, response.Results[4].StatusCode)
+    ast.NotNil(server.Instance("PAY", "pay-1"))
+    ast.Equal(meta.StatusOutOfService, server.Instance("ORDER", "order-1").Status)
+    ast.Nil(server.Instance("ORDER", "order-2"))
+
+    response = client.SimpleBatchReplicate(server.URL, nil)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Empty(response.Results)
+}
+
+func TestHttpClient_QueryServerStatus(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client := &HttpClient{}
+
+    response := client.SimpleQueryServerStatus(server.URL)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(200, response.StatusCode)
+    ast.Equal("test", response.Status.GeneralStats["environment"])
+    ast.Contains(response.Status.ApplicationStats, "available-replicas")
+    ast.Equal("eureka-1", response.Status.InstanceInfo.InstanceId)
+    ast.Equal(meta.StatusUp, response.Status.InstanceInfo.Status)
+
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        _, _ = w.Write([]byte("<html/>"))
+        return true
+    })
+    response = client.SimpleQueryServerStatus(server.URL)
+    ast.True(errors.Is(response.Error, ErrDecode))
+    overrides := client.SimpleQueryStatusOverrides(server.URL)
+    ast.True(errors.Is(overrides.Error, ErrDecode))
+}
+
+func TestHttpClient_ModifyMetadataEncoding(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp, Metadata: map[string]string{"zone": "zone1"}})
+    client := &HttpClient{}
+
+    metadata := map[string]string{
+        "version":     "v1",
+        "description": "a b&c=d",
+        "owner.name":  "张三",
+        "empty":       "",
+    }
+    response := client.SimpleModifyMetadata(server.URL, "ORDER", "order-1", metadata)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal("PUT /apps/ORDER/order-1/metadata?description=a+b%26c%3Dd&empty=&owner.name=%E5%BC%A0%E4%B8%89&version=v1", server.Requests()[0])
+    stored := server.Instance("ORDER", "order-1").Metadata
+    for key, value := range metadata {
+        ast.Equal(value, stored[key])
+    }
+    ast.Equal("zone1", stored["zone"])
+
+    // 空元数据不拼接查询参数
+    response = client.SimpleModifyMetadata(server.URL, "ORDER", "order-1", map[string]string{})
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal("PUT /apps/ORDER/order-1/metadata", server.Requests()[1])
+    ast.Nil(client.SimpleModifyMetadata(server.URL, "ORDER", "order-1", nil).Error)
+
+    // 非法key不发送请求
+    for _, key := range []string{"", "1abc", "a b", "a&b", "@class", "$"} {
+        response = client.SimpleModifyMetadata(server.URL, "ORDER", "order-1", map[string]string{key: "v"})
+        ast.NotNil(response.Error, key)
+    }
+    ast.Len(server.Requests(), 3)
+}
+
+func TestHttpClient_Compression(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    for i := 0; i < 20; i++ {
+        server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: fmt.Sprintf("order-%d", i), Status: meta.StatusUp})
+    }
+    var gzipRequests int32
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        if r.Header.Get("Accept-Encoding") != "gzip" {
+            return false
+        }
+        atomic.AddInt32(&gzipRequests, 1)
+        // 压缩默认处理的响应内容
+        recorder := httptest.NewRecorder()
+        r.Header.Del("Accept-Encoding")
+        server.serve(recorder, r)
+        w.Header().Set("Content-Encoding", "gzip")
+        w.WriteHeader(recorder.Code)
+        writer := gzip.NewWriter(w)
+        _, _ = writer.Write(recorder.Body.Bytes())
+        _ = writer.Close()
+        return true
+    })
+
+    client := &HttpClient{}
+    compressed := client.SimpleQueryApps(server.URL)
+    ast.Nilf(compressed.Error, "%v", compressed.Error)
+    ast.Equal(int32(1), atomic.LoadInt32(&gzipRequests))
+    ast.True(compressed.Response.Compressed)
+    ast.Len(compressed.Apps, 1)
+    ast.Len(compressed.Apps[0].Instances, 20)
+    ast.Equal(compressed.Response.BytesTransferred, compressed.BytesTransferred)
+    // 服务列表直接从响应流解析, 不保留响应内容
+    ast.Empty(compressed.Response.Body)
+
+    // 禁用压缩
+    client.DisableCompression = true
+    plain := client.SimpleQueryApps(server.URL)
+    ast.Nilf(plain.Error, "%v", plain.Error)
+    ast.False(plain.Response.Compressed)
+    ast.Empty(plain.Response.Body)
+    request, err := http.NewRequest(http.MethodGet, server.URL+"/apps", nil)
+    ast.Nilf(err, "%v", err)
+    request.Header.Set("Accept-Encoding", "identity")
+    raw, err := http.DefaultClient.Do(request)
+    ast.Nilf(err, "%v", err)
+    body, err := ioutil.ReadAll(raw.Body)
+    _ = raw.Body.Close()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(int64(len(body)), plain.BytesTransferred)
+    ast.Less(compressed.BytesTransferred, plain.BytesTransferred)
+    ast.Equal(int32(1), atomic.LoadInt32(&gzipRequests))
+}
+
+func TestHttpClient_QueryAppsStreaming(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp, VipAddress: "order"})
+    client := &HttpClient{}
+
+    // 服务列表直接从响应流解析, 不保留响应内容
+    for _, response := range []*AppsResponse{
+        client.SimpleQueryApps(server.URL),
+        client.SimpleQueryAppsConditional(server.URL, nil),
+        client.SimpleQueryAppsDelta(server.URL),
+        client.SimpleQueryVipApps(server.URL, "order"),
+    } {
+        ast.Nilf(response.Error, "%v", response.Error)
+        ast.Len(response.Apps, 1)
+        ast.Empty(response.Response.Body)
+        ast.Greater(response.BytesTransferred, int64(0))
+    }
+
+    // 响应内容解析失败
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusOK)
+        _, _ = w.Write([]byte(`{"applications":{"application":[`))
+        return true
+    })
+    response := client.SimpleQueryApps(server.URL)
+    ast.True(errors.Is(response.Error, ErrDecode))
+    ast.Nil(response.Response.Error)
+
+    // 读取响应失败视为通讯失败, 尝试下一个服务地址
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.Header().Set("Content-Length", "1024")
+        w.WriteHeader(http.StatusOK)
+        _, _ = w.Write([]byte(`{"applications":{"application":[`))
+        return true
+    })
+    backup := newTestEurekaServer()
+    defer backup.Close()
+    backup.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    response = client.SimpleQueryApps(server.URL + "," + backup.URL)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Len(response.Response.Responses, 2)
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrServerUnavailable))
+    ast.Len(response.Apps, 1)
+}
+
+func TestHttpClient_QueryAppsConditional(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    client := &HttpClient{}
+
+    // 响应内容未变化时复用上次查询结果
+    first := client.SimpleQueryAppsConditional(server.URL, nil)
+    ast.Nilf(first.Error, "%v", first.Error)
+    ast.False(first.CacheHit)
+    ast.Len(first.Apps, 1)
+    second := client.SimpleQueryAppsConditional(server.URL, first)
+    ast.Nilf(second.Error, "%v", second.Error)
+    ast.True(second.CacheHit)
+    ast.Same(first.Apps[0], second.Apps[0])
+    ast.Greater(second.BytesTransferred, int64(0))
+
+    // 服务列表变化时重新解析
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusUp})
+    third := client.SimpleQueryAppsConditional(server.URL, second)
+    ast.Nilf(third.Error, "%v", third.Error)
+    ast.False(third.CacheHit)
+    ast.Len(third.Apps[0].Instances, 2)
+
+    // 服务实例变更(hashcode不变, lastDirtyTimestamp变化)时重新解析
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusUp, LastDirtyTimestamp: "2", Metadata: map[string]string{"version": "2"}})
+    changed := client.SimpleQueryAppsConditional(server.URL, third)
+    ast.Nilf(changed.Error, "%v", changed.Error)
+    ast.Equal(third.Applications.AppsHashcode, changed.Applications.AppsHashcode)
+    ast.False(changed.CacheHit)
+    third = changed
+
+    // 支持ETag时携带If-None-Match, 304时复用上次查询结果
+    var ifNoneMatch []string
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+        if r.Header.Get("If-None-Match") == `"v1"` {
+            w.WriteHeader(http.StatusNotModified)
+            return true
+        }
+        w.Header().Set("ETag", `"v1"`)
+        return false
+    })
+    fourth := client.SimpleQueryAppsConditional(server.URL, third)
+    ast.Nilf(fourth.Error, "%v", fourth.Error)
+    ast.Equal(`"v1"`, fourth.ETag)
+    fifth := client.SimpleQueryAppsConditional(server.URL, fourth)
+    ast.Nilf(fifth.Error, "%v", fifth.Error)
+    ast.Equal(http.StatusNotModified, fifth.StatusCode)
+    ast.True(fifth.CacheHit)
+    ast.Equal(`"v1"`, fifth.ETag)
+    ast.Len(fifth.Apps[0].Instances, 2)
+    ast.Equal([]string{"", `"v1"`}, ifNoneMatch)
+
+    // 上次查询失败时不携带If-None-Match
+    failed := &AppsResponse{Error: errors.New("failed"), ETag: `"v1"`}
+    ast.False(client.SimpleQueryAppsConditional(server.URL, failed).CacheHit)
+}
+
+func TestHttpClient_QueryAppsEnvelope(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-1", Status: meta.StatusUp})
+    server.Put(&meta.InstanceInfo{AppName: "ORDER", InstanceId: "order-2", Status: meta.StatusDown})
+    client := &HttpClient{}
+
+    response := client.SimpleQueryApps(server.URL)
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int64(1), response.Applications.VersionsDelta)
+    ast.Equal("DOWN_1_UP_1_", response.Applications.AppsHashcode)
+    ast.Equal(response.Apps, response.Applications.Apps)
+    // 本地计算的hashcode与eureka server一致时服务列表一致
+    ast.Equal(response.Applications.AppsHashcode, meta.ComputeAppsHashcode(response.Apps))
+
+    delta := client.SimpleQueryAppsDelta(server.URL)
+    ast.Nilf(delta.Error, "%v", delta.Error)
+    ast.Equal("DOWN_1_UP_1_", delta.Applications.AppsHashcode)
+
+    // 命中缓存时复用上次响应根节点
+    cached := client.SimpleQueryAppsConditional(server.URL, response)
+    ast.True(cached.CacheHit)
+    ast.Same(response.Applications, cached.Applications)
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/log"
+    "sync"
+)
+
+// listenerRegistry 监听注册表(零值可用), F 为监听函数类型
+type listenerRegistry[F any] struct {
+    listeners map[int]F
+    seq       int
+    mutex     sync.Mutex
+}
+
+// add 添加监听, 返回移除监听函数
+func (registry *listenerRegistry[F]) add(listener F) (remove func()) {
+    registry.mutex.Lock()
+    defer registry.mutex.Unlock()
+    if registry.listeners == nil {
+        registry.listeners = make(map[int]F)
+    }
+    registry.seq++
+    id := registry.seq
+    registry.listeners[id] = listener
+    return func() {
+        registry.mutex.Lock()
+        defer registry.mutex.Unlock()
+        delete(registry.listeners, id)
+    }
+}
+
+// notify 依次回调所有监听(回调时不持有锁), 单个监听panic时记录日志并继续回调其他监听
+func (registry *listenerRegistry[F]) notify(logger log.Logger, name string, call func(listener F)) {
+    registry.mutex.Lock()
+    listeners := make([]F, 0, len(registry.listeners))
+    for _, listener := range registry.listeners {
+        listeners = append(listeners, listener)
+    }
+    registry.mutex.Unlock()
+    for _, listener := range listeners {
+        func() {
+            defer func() {
+                if rc := recover(); rc != nil {
+                    logger.Errorf("%s, listener recover error: %v", name, rc)
+                }
+            }()
+            call(listener)
+        }()
+    }
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/log"
+    "github.com/stretchr/testify/assert"
+    "sync/atomic"
+    "testing"
+)
+
+func TestListenerRegistry(t *testing.T) {
+    ast := assert.New(t)
+    logger := &testErrorLogger{Logger: log.DefaultLoggerImpl}
+    registry := &listenerRegistry[func(value int)]{}
+    sum := 0
+    remove := registry.add(func(value int) {
+        sum += value
+    })
+    // 单个监听panic时不影响其他监听
+    registry.add(func(value int) {
+        panic("listener panic")
+    })
+    registry.notify(logger, "TestListenerRegistry", func(listener func(value int)) {
+        listener(1)
+    })
+    ast.Equal(1, sum)
+    ast.Equal(int32(1), atomic.LoadInt32(&logger.errors))
+
+    // 移除后不再回调
+    remove()
+    registry.notify(logger, "TestListenerRegistry", func(listener func(value int)) {
+        listener(1)
+    })
+    ast.Equal(1, sum)
+    ast.Equal(int32(2), atomic.LoadInt32(&logger.errors))
+}
@@ -3,6 +3,7 @@ package client
 import (
     "github.com/jiashunx/eureka-client-go/meta"
     "net/http"
+    "time"
 )
 
 // EurekaRequest 与eureka server通讯请求模型
@@ -25,6 +26,15 @@ type EurekaResponse struct {
     Request      *EurekaRequest
     Error        error
     Responses    []*EurekaResponse
+    // 同一eureka server服务地址的第几次尝试(从1开始)
+    Attempt int
+    // 响应内容实际传输字节数(压缩时为压缩后字节数)
+    BytesTransferred int64
+    // 响应内容是否经gzip压缩
+    Compressed bool
+    // 从响应流直接解析的响应内容及解析错误(此时不保留Body)
+    decoded   interface{}
+    decodeErr error
 }
 
 // CommonResponse 通用处理接口请求响应
@@ -56,6 +66,40 @@ type AppsResponse struct {
     StatusCode int
     Error      error
     Apps       []*meta.AppInfo
+    // 服务列表响应根节点(含服务列表版本及hashcode), Applications.Apps 与 Apps 一致
+    Applications *meta.Applications
+    // 响应ETag(eureka server支持时)
+    ETag string
+    // 是否命中缓存(eureka server返回304或服务列表未变化, 复用上次查询结果的服务列表)
+    CacheHit bool
+    // 响应内容实际传输字节数
+    BytesTransferred int64
+}
+
+// ServerStatusResponse eureka server状态查询接口请求响应
+type ServerStatusResponse struct {
+    Response   *EurekaResponse
+    StatusCode int
+    Error      error
+    Status     *meta.ServerStatus
+}
+
+// StatusOverridesResponse eureka server服务实例覆盖状态查询接口请求响应
+type StatusOverridesResponse struct {
+    Response   *EurekaResponse
+    StatusCode int
+    Error      error
+    // key为服务实例ID
+    Overrides map[string]meta.InstanceStatus
+}
+
+// ReplicationResponse eureka server节点间批量复制接口请求响应
+type ReplicationResponse struct {
+    Response   *EurekaResponse
+    StatusCode int
+    Error      error
+    // 与请求中的服务实例操作一一对应
+    Results []*meta.ReplicationInstanceResponse
 }
 
 // EurekaConfigOptions eureka客户端配置冗余信息（可选）
@@ -63,3 +107,16 @@ type EurekaConfigOptions struct {
     // 心跳后回调, 仅当集成到 EurekaClient 时有效
     HeartbeatFunc func(*CommonResponse)
 }
+
+// GracefulStopOptions eureka客户端优雅关闭配置
+type GracefulStopOptions struct {
+    // 服务实例状态变更为 meta.StatusOutOfService 后的等待时长(等待其他客户端刷新服务列表), 默认: RegistryFetchIntervalSeconds, 小于0时不等待
+    DrainPeriod time.Duration
+    // 等待时长结束后持续等待直至该函数返回true(如: 处理中的请求数为0), 可选
+    Drained func() bool
+    // 检查 Drained 的时间间隔, 默认: DefaultDrainCheckInterval
+    DrainCheckInterval time.Duration
+}
+
+// DefaultDrainCheckInterval 默认检查 GracefulStopOptions.Drained 的时间间隔
+var DefaultDrainCheckInterval = 100 * time.Millisecond
@@ -0,0 +1,329 @@
+package client
+
+import (
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/log"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "strings"
+    "sync"
+    "time"
+)
+
+var (
+    // DefaultOutlierConsecutiveFailures 默认连续失败次数阈值
+    DefaultOutlierConsecutiveFailures  = 5
+    // DefaultOutlierErrorRateThreshold 默认错误率阈值
+    DefaultOutlierErrorRateThreshold   = 0.5
+    // DefaultOutlierMinimumRequests 默认统计周期内计算错误率的最小请求数
+    DefaultOutlierMinimumRequests      = 10
+    // DefaultOutlierInterval 默认错误率统计周期
+    DefaultOutlierInterval             = 30 * time.Second
+    // DefaultOutlierBaseEjectionDuration 默认基础摘除时长
+    DefaultOutlierBaseEjectionDuration = 30 * time.Second
+    // DefaultOutlierMaxEjectionDuration 默认最大摘除时长
+    DefaultOutlierMaxEjectionDuration  = 5 * time.Minute
+    // DefaultOutlierStatsIdleTimeout 默认服务实例统计空闲过期时长
+    DefaultOutlierStatsIdleTimeout     = 10 * time.Minute
+)
+
+// OutlierConfig 异常服务实例检测配置
+type OutlierConfig struct {
+    // 连续失败次数达到该值时摘除服务实例, 默认: DefaultOutlierConsecutiveFailures, 小于0时不启用
+    ConsecutiveFailures int
+    // 统计周期内错误率达到该值时摘除服务实例(取值范围: (0, 1]), 默认: DefaultOutlierErrorRateThreshold, 小于0时不启用
+    ErrorRateThreshold float64
+    // 统计周期内请求数达到该值时才计算错误率, 默认: DefaultOutlierMinimumRequests
+    MinimumRequests int
+    // 错误率统计周期, 默认: DefaultOutlierInterval
+    Interval time.Duration
+    // 基础摘除时长(实际摘除时长=基础摘除时长*累计摘除次数), 默认: DefaultOutlierBaseEjectionDuration
+    BaseEjectionDuration time.Duration
+    // 最大摘除时长, 默认: DefaultOutlierMaxEjectionDuration
+    MaxEjectionDuration time.Duration
+    // 服务实例统计空闲过期时长(超过该时长未上报调用结果且未被摘除时清理统计, 如: 已下线的服务实例), 默认: DefaultOutlierStatsIdleTimeout
+    StatsIdleTimeout time.Duration
+}
+
+// OutlierEventType 异常服务实例事件类型
+type OutlierEventType string
+
+const (
+    OutlierEjected  OutlierEventType = "EJECTED"
+    OutlierRestored OutlierEventType = "RESTORED"
+)
+
+// OutlierEvent 异常服务实例事件
+type OutlierEvent struct {
+    Type       OutlierEventType
+    AppName    string
+    InstanceId string
+    // 摘除原因, 仅 OutlierEjected 事件有效
+    Reason string
+    // 摘除截止时间, 仅 OutlierEjected 事件有效
+    EjectedUntil time.Time
+    Time         time.Time
+}
+
+// outlierStats 服务实例调用统计
+type outlierStats struct {
+    appName             string
+    instanceId          string
+    consecutiveFailures int
+    intervalStart       time.Time
+    requests            int
+    failures            int
+    ejections           int
+    ejectedUntil        time.Time
+    restoredAt          time.Time
+    lastReport          time.Time
+}
+
+// OutlierDetector 异常服务实例检测(被动摘除连续失败或错误率过高的服务实例)
+type OutlierDetector struct {
+    Logger    log.Logger
+    config    *OutlierConfig
+    stats     map[string]*outlierStats
+    mutex     sync.Mutex
+    listeners listenerRegistry[func(event *OutlierEvent)]
+    now       func() time.Time
+    // 上次清理过期统计时间
+    lastSweep time.Time
+}
+
+// NewOutlierDetector 根据 *OutlierConfig 创建异常服务实例检测
+func NewOutlierDetector(config *OutlierConfig) *OutlierDetector {
+    nc := &OutlierConfig{}
+    if config != nil {
+        *nc = *config
+    }
+    if nc.ConsecutiveFailures == 0 {
+        nc.ConsecutiveFailures = DefaultOutlierConsecutiveFailures
+    }
+    if nc.ErrorRateThreshold == 0 {
+        nc.ErrorRateThreshold = DefaultOutlierErrorRateThreshold
+    }
+    if nc.MinimumRequests <= 0 {
+        nc.MinimumRequests = DefaultOutlierMinimumRequests
+    }
+    if nc.Interval <= 0 {
+        nc.Interval = DefaultOutlierInterval
+    }
+    if nc.BaseEjectionDuration <= 0 {
+        nc.BaseEjectionDuration = DefaultOutlierBaseEjectionDuration
+    }
+    if nc.MaxEjectionDuration <= 0 {
+        nc.MaxEjectionDuration = DefaultOutlierMaxEjectionDuration
+    }
+    if nc.StatsIdleTimeout <= 0 {
+        nc.StatsIdleTimeout = DefaultOutlierStatsIdleTimeout
+    }
+    return &OutlierDetector{
+        config: nc,
+        stats:  make(map[string]*outlierStats),
+        now:    time.Now,
+    }
+}
+
+// GetLogger 获取日志对象
+func (detector *OutlierDetector) GetLogger() log.Logger {
+    if detector.Logger == nil {
+        detector.Logger = log.DefaultLoggerImpl
+    }
+    return detector.Logger
+}
+
+// ReportSuccess 上报服务实例调用成功
+func (detector *OutlierDetector) ReportSuccess(instance *meta.InstanceInfo) {
+    detector.report(instance, true)
+}
+
+// ReportFailure 上报服务实例调用失败
+func (detector *OutlierDetector) ReportFailure(instance *meta.InstanceInfo) {
+    detector.report(instance, false)
+}
+
+// IsEjected 服务实例是否已被摘除
+func (detector *OutlierDetector) IsEjected(instance *meta.InstanceInfo) bool {
+    if instance == nil {
+        return false
+    }
+    detector.mutex.Lock()
+    stats, ok := detector.stats[outlierKey(instance)]
+    var event *OutlierEvent
+    ejected := false
+    if ok {
+        ejected, event = detector.checkEjected(stats)
+    }
+    detector.mutex.Unlock()
+    detector.publish(event)
+    return ejected
+}
+
+// Filter 过滤已被摘除的服务实例(若全部服务实例均被摘除, 则返回原列表以避免服务完全不可用)
+func (detector *OutlierDetector) Filter(instances []*meta.InstanceInfo) []*meta.InstanceInfo {
+    filtered := detector.exclude(instances)
+    if len(filtered) == 0 {
+        return instances
+    }
+    return filtered
+}
+
+// exclude 排除已被摘除的服务实例(全部被摘除时返回空列表)
+func (detector *OutlierDetector) exclude(instances []*meta.InstanceInfo) []*meta.InstanceInfo {
+    filtered := make([]*meta.InstanceInfo, 0)
+    for _, instance := range instances {
+        if !detector.IsEjected(instance) {
+            filtered = append(filtered, instance)
+        }
+    }
+    return filtered
+}
+
+// EjectedInstances 获取当前已被摘除的服务实例ID列表(key为服务名称, 大写)
+func (detector *OutlierDetector) EjectedInstances() map[string][]string {
+    events := make([]*OutlierEvent, 0)
+    ejected := make(map[string][]string)
+    detector.mutex.Lock()
+    detector.sweep()
+    for _, stats := range detector.stats {
+        b, event := detector.checkEjected(stats)
+        if event != nil {
+            events = append(events, event)
+        }
+        if b {
+            ejected[stats.appName] = append(ejected[stats.appName], stats.instanceId)
+        }
+    }
+    detector.mutex.Unlock()
+    for _, event := range events {
+        detector.publish(event)
+    }
+    return ejected
+}
+
+// Subscribe 订阅异常服务实例事件, 返回取消订阅函数
+func (detector *OutlierDetector) Subscribe(listener func(event *OutlierEvent)) (unsubscribe func()) {
+    return detector.listeners.add(listener)
+}
+
+// report 记录服务实例调用结果, 达到阈值时摘除服务实例
+func (detector *OutlierDetector) report(instance *meta.InstanceInfo, success bool) {
+    if instance == nil {
+        return
+    }
+    key := outlierKey(instance)
+    detector.mutex.Lock()
+    detector.sweep()
+    stats, ok := detector.stats[key]
+    if !ok {
+        stats = &outlierStats{
+            appName:       strings.ToUpper(instance.AppName),
+            instanceId:    instance.InstanceId,
+            intervalStart: detector.now(),
+        }
+        detector.stats[key] = stats
+    }
+    stats.lastReport = detector.now()
+    ejected, restoredEvent := detector.checkEjected(stats)
+    var ejectedEvent *OutlierEvent
+    if !ejected {
+        now, config := detector.now(), detector.config
+        if now.Sub(stats.intervalStart) >= config.Interval {
+            stats.intervalStart, stats.requests, stats.failures = now, 0, 0
+        }
+        stats.requests++
+        if success {
+            stats.consecutiveFailures = 0
+        } else {
+            stats.failures++
+            stats.consecutiveFailures++
+        }
+        reason := ""
+        if config.ConsecutiveFailures > 0 && stats.consecutiveFailures >= config.ConsecutiveFailures {
+            reason = fmt.Sprintf("consecutive failures: %d", stats.consecutiveFailures)
+        } else if config.ErrorRateThreshold > 0 && stats.requests >= config.MinimumRequests {
+            if rate := float64(stats.failures) / float64(stats.requests); rate >= config.ErrorRateThreshold {
+                reason = fmt.Sprintf("error rate: %.2f, requests: %d", rate, stats.requests)
+            }
+        }
+        if reason != "" {
+            ejectedEvent = detector.eject(stats, reason)
+        }
+    }
+    detector.mutex.Unlock()
+    detector.publish(restoredEvent)
+    detector.publish(ejectedEvent)
+}
+
+// sweep 清理过期统计(每个空闲过期时长最多执行一次, 需持有锁): 超过空闲过期时长未上报调用结果且不在摘除期内的服务实例(摘除已到期的不再发送恢复事件)
+func (detector *OutlierDetector) sweep() {
+    now, timeout := detector.now(), detector.config.StatsIdleTimeout
+    if now.Sub(detector.lastSweep) < timeout {
+        return
+    }
+    detector.lastSweep = now
+    for key, stats := range detector.stats {
+        if !now.Before(stats.ejectedUntil) && now.Sub(stats.lastReport) >= timeout {
+            delete(detector.stats, key)
+        }
+    }
+}
+
+// eject 摘除服务实例
+func (detector *OutlierDetector) eject(stats *outlierStats, reason string) *OutlierEvent {
+    now, config := detector.now(), detector.config
+    if !stats.restoredAt.IsZero() && now.Sub(stats.restoredAt) >= config.MaxEjectionDuration {
+        stats.ejections = 0
+    }
+    stats.ejections++
+    duration := config.BaseEjectionDuration * time.Duration(stats.ejections)
+    if duration > config.MaxEjectionDuration || duration <= 0 {
+        duration = config.MaxEjectionDuration
+    }
+    stats.ejectedUntil = now.Add(duration)
+    return &OutlierEvent{
+        Type:         OutlierEjected,
+        AppName:      stats.appName,
+        InstanceId:   stats.instanceId,
+        Reason:       reason,
+        EjectedUntil: stats.ejectedUntil,
+        Time:         now,
+    }
+}
+
+// checkEjected 检查服务实例是否处于摘除状态, 摘除到期时恢复服务实例并重置统计
+func (detector *OutlierDetector) checkEjected(stats *outlierStats) (bool, *OutlierEvent) {
+    if stats.ejectedUntil.IsZero() {
+        return false, nil
+    }
+    now := detector.now()
+    if now.Before(stats.ejectedUntil) {
+        return true, nil
+    }
+    stats.ejectedUntil = time.Time{}
+    stats.restoredAt = now
+    stats.consecutiveFailures, stats.requests, stats.failures = 0, 0, 0
+    stats.intervalStart = now
+    return false, &OutlierEvent{
+        Type:       OutlierRestored,
+        AppName:    stats.appName,
+        InstanceId: stats.instanceId,
+        Time:       now,
+    }
+}
+
+// publish 发布异常服务实例事件
+func (detector *OutlierDetector) publish(event *OutlierEvent) {
+    if event == nil {
+        return
+    }
+    detector.GetLogger().Tracef("OutlierDetector.publish, %s >>> app: %s, instanceId: %s, reason: %s", event.Type, event.AppName, event.InstanceId, event.Reason)
+    detector.listeners.notify(detector.GetLogger(), "OutlierDetector.publish", func(listener func(event *OutlierEvent)) {
+        listener(event)
+    })
+}
+
+// outlierKey 服务实例统计key
+func outlierKey(instance *meta.InstanceInfo) string {
+    return strings.ToUpper(instance.AppName) + "/" + instance.InstanceId
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+    "errors"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestOutlierDetector_ConsecutiveFailures(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    detector := NewOutlierDetector(&OutlierConfig{
+        ConsecutiveFailures:  3,
+        ErrorRateThreshold:   -1,
+        BaseEjectionDuration: 10 * time.Second,
+        MaxEjectionDuration:  15 * time.Second,
+    })
+    detector.now = func() time.Time { return now }
+    events := make([]*OutlierEvent, 0)
+    detector.Subscribe(func(event *OutlierEvent) {
+        events = append(events, event)
+    })
+    instance := newTestQueryInstance("order", "order-1", "order-1", "zone1", meta.StatusUp, nil, now)
+
+    detector.ReportFailure(instance)
+    detector.ReportFailure(instance)
+    detector.ReportSuccess(instance)
+    detector.ReportFailure(instance)
+    detector.ReportFailure(instance)
+    ast.False(detector.IsEjected(instance))
+    detector.ReportFailure(instance)
+    ast.True(detector.IsEjected(instance))
+    ast.Equal(1, len(events))
+    ast.Equal(OutlierEjected, events[0].Type)
+    ast.Equal("ORDER", events[0].AppName)
+    ast.Equal(now.Add(10*time.Second), events[0].EjectedUntil)
+    ast.Equal([]string{"order-1"}, detector.EjectedInstances()["ORDER"])
+
+    // 摘除到期后恢复
+    now = now.Add(10 * time.Second)
+    ast.False(detector.IsEjected(instance))
+    ast.Equal(2, len(events))
+    ast.Equal(OutlierRestored, events[1].Type)
+
+    // 再次摘除时摘除时长递增(不超过最大摘除时长)
+    for i := 0; i < 3; i++ {
+        detector.ReportFailure(instance)
+    }
+    ast.True(detector.IsEjected(instance))
+    ast.Equal(now.Add(15*time.Second), events[2].EjectedUntil)
+}
+
+func TestOutlierDetector_ErrorRate(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    detector := NewOutlierDetector(&OutlierConfig{
+        ConsecutiveFailures: -1,
+        ErrorRateThreshold:  0.5,
+        MinimumRequests:     4,
+        Interval:            time.Minute,
+    })
+    detector.now = func() time.Time { return now }
+    instance := newTestQueryInstance("order", "order-1", "order-1", "zone1", meta.StatusUp, nil, now)
+
+    detector.ReportFailure(instance)
+    detector.ReportSuccess(instance)
+    detector.ReportSuccess(instance)
+    ast.False(detector.IsEjected(instance))
+
+    // 统计周期结束后重新统计
+    now = now.Add(time.Minute)
+    detector.ReportFailure(instance)
+    detector.ReportSuccess(instance)
+    detector.ReportFailure(instance)
+    ast.False(detector.IsEjected(instance))
+    detector.ReportSuccess(instance)
+    ast.True(detector.IsEjected(instance))
+}
+
+func TestDiscoveryClient_OutlierDetector(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    order1 := newTestQueryInstance("ORDER", "order-1", "order-1", "zone1", meta.StatusUp, nil, now)
+    order2 := newTestQueryInstance("ORDER", "order-2", "order-2", "zone1", meta.StatusUp, nil, now)
+    discovery := newTestDiscoveryClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {{Name: "ORDER", Instances: []*meta.InstanceInfo{order1, order2}}},
+    })
+    discovery.OutlierDetector = NewOutlierDetector(&OutlierConfig{ConsecutiveFailures: 1})
+    discovery.OutlierDetector.ReportFailure(order1)
+    for i := 0; i < 10; i++ {
+        instance, err := discovery.FilterAppInstance(discovery.Apps, "ORDER")
+        ast.Nilf(err, "%v", err)
+        ast.Equal("order-2", instance.InstanceId)
+    }
+
+    // 全部服务实例均被摘除时不排除任何服务实例
+    discovery.OutlierDetector.ReportFailure(order2)
+    app, err := discovery.FilterApp(discovery.Apps, "ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Equal(2, len(app.Instances))
+}
+
+func TestDiscoveryClient_OutlierDetectorZoneFailover(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    local1 := newTestQueryInstance("ORDER", "order-1", "order-1", "zone1", meta.StatusUp, nil, now)
+    local2 := newTestQueryInstance("ORDER", "order-2", "order-2", "zone1", meta.StatusUp, nil, now)
+    remote := newTestQueryInstance("ORDER", "order-3", "order-3", "zone2", meta.StatusUp, nil, now)
+    discovery := newTestDiscoveryClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {{Name: "ORDER", Instances: []*meta.InstanceInfo{local1, local2}}},
+        "zone2": {{Name: "ORDER", Instances: []*meta.InstanceInfo{remote}}},
+    })
+    discovery.OutlierDetector = NewOutlierDetector(&OutlierConfig{ConsecutiveFailures: 1})
+
+    // 当前zone服务实例全部被摘除时使用其他zone的服务实例
+    discovery.OutlierDetector.ReportFailure(local1)
+    discovery.OutlierDetector.ReportFailure(local2)
+    for i := 0; i < 10; i++ {
+        instance, err := discovery.FilterAppInstance(discovery.Apps, "ORDER")
+        ast.Nilf(err, "%v", err)
+        ast.Equal("order-3", instance.InstanceId)
+    }
+    instances, err := discovery.FilterInstancesByVip(discovery.Apps, "ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Len(instances, 1)
+    apps, err := discovery.FilterAppsBySvip(discovery.Apps, "ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Equal("order-3", apps[0].Instances[0].InstanceId)
+
+    // 所有zone服务实例均被摘除时不排除任何服务实例(优先当前zone)
+    discovery.OutlierDetector.ReportFailure(remote)
+    app, err := discovery.FilterApp(discovery.Apps, "ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Len(app.Instances, 2)
+    ast.Equal("zone1", app.Instances[0].Zone)
+
+    // 无服务时仍返回无可用服务错误
+    _, err = discovery.FilterApp(discovery.Apps, "USER")
+    ast.True(errors.Is(err, ErrNoAvailableInstance))
+}
+
+func TestOutlierDetector_StatsIdleTimeout(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    detector := NewOutlierDetector(&OutlierConfig{
+        ConsecutiveFailures:  1,
+        BaseEjectionDuration: 30 * time.Minute,
+        MaxEjectionDuration:  30 * time.Minute,
+        StatsIdleTimeout:     time.Minute,
+    })
+    detector.now = func() time.Time { return now }
+    ejected := newTestQueryInstance("order", "order-1", "order-1", "zone1", meta.StatusUp, nil, now)
+    idle := newTestQueryInstance("order", "order-2", "order-2", "zone1", meta.StatusUp, nil, now)
+    active := newTestQueryInstance("order", "order-3", "order-3", "zone1", meta.StatusUp, nil, now)
+
+    detector.ReportFailure(ejected)
+    detector.ReportSuccess(idle)
+    detector.ReportSuccess(active)
+    ast.Equal(3, len(detector.stats))
+
+    // 空闲过期的服务实例统计被清理, 摘除期内的服务实例统计保留
+    now = now.Add(time.Minute)
+    detector.ReportSuccess(active)
+    ast.Equal(2, len(detector.stats))
+    ast.NotNil(detector.stats[outlierKey(ejected)])
+    ast.NotNil(detector.stats[outlierKey(active)])
+    ast.True(detector.IsEjected(ejected))
+
+    // 摘除到期且空闲过期后清理
+    now = now.Add(30 * time.Minute)
+    ast.Equal(map[string][]string{}, detector.EjectedInstances())
+    ast.Equal(0, len(detector.stats))
+}
@@ -0,0 +1,310 @@
+package client
+
+import (
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+)
+
+// InstanceComparator 服务实例排序比较函数(a排在b之前时返回true)
+type InstanceComparator func(a, b *meta.InstanceInfo) bool
+
+// InstancePredicate 服务实例过滤条件
+type InstancePredicate func(instance *meta.InstanceInfo) bool
+
+// CompareByInstanceId 按InstanceId升序排序
+var CompareByInstanceId InstanceComparator = func(a, b *meta.InstanceInfo) bool {
+    return a.InstanceId < b.InstanceId
+}
+
+// CompareByHostName 按HostName升序排序
+var CompareByHostName InstanceComparator = func(a, b *meta.InstanceInfo) bool {
+    return a.HostName < b.HostName
+}
+
+// CompareByZone 按Zone升序排序
+var CompareByZone InstanceComparator = func(a, b *meta.InstanceInfo) bool {
+    return a.Zone < b.Zone
+}
+
+// CompareByLeaseAge 按租约时长降序排序(注册最早的服务实例排在最前)
+var CompareByLeaseAge InstanceComparator = func(a, b *meta.InstanceInfo) bool {
+    return registrationTimestamp(a) < registrationTimestamp(b)
+}
+
+// HasMetadata 服务实例包含指定元数据key
+func HasMetadata(key string) InstancePredicate {
+    return func(instance *meta.InstanceInfo) bool {
+        _, ok := instance.Metadata[key]
+        return ok
+    }
+}
+
+// MetadataEquals 服务实例元数据key对应值与value相等
+func MetadataEquals(key, value string) InstancePredicate {
+    return func(instance *meta.InstanceInfo) bool {
+        v, ok := instance.Metadata[key]
+        return ok && v == value
+    }
+}
+
+// MetadataHasPrefix 服务实例元数据key对应值以prefix开头
+func MetadataHasPrefix(key, prefix string) InstancePredicate {
+    return func(instance *meta.InstanceInfo) bool {
+        v, ok := instance.Metadata[key]
+        return ok && strings.HasPrefix(v, prefix)
+    }
+}
+
+// AllOf 同时满足所有过滤条件
+func AllOf(predicates ...InstancePredicate) InstancePredicate {
+    return func(instance *meta.InstanceInfo) bool {
+        for _, predicate := range predicates {
+            if predicate != nil && !predicate(instance) {
+                return false
+            }
+        }
+        return true
+    }
+}
+
+// InstanceQuery 基于服务发现缓存的服务实例查询构造器
+type InstanceQuery struct {
+    discovery  *DiscoveryClient
+    predicates []InstancePredicate
+    comparator InstanceComparator
+    limit      int
+    err        error
+    now        func() time.Time
+}
+
+// Query 创建基于当前服务发现缓存的服务实例查询
+func (discovery *DiscoveryClient) Query() *InstanceQuery {
+    return &InstanceQuery{
+        discovery:  discovery,
+        predicates: make([]InstancePredicate, 0),
+        now:        time.Now,
+    }
+}
+
+// Where 添加自定义过滤条件
+func (query *InstanceQuery) Where(predicate InstancePredicate) *InstanceQuery {
+    if predicate != nil {
+        query.predicates = append(query.predicates, predicate)
+    }
+    return query
+}
+
+// App 过滤指定服务名称(忽略大小写)
+func (query *InstanceQuery) App(appName string) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        return strings.EqualFold(instance.AppName, appName)
+    })
+}
+
+// Vip 过滤指定vip
+func (query *InstanceQuery) Vip(vip string) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        return instance.VipAddress == vip
+    })
+}
+
+// Svip 过滤指定svip
+func (query *InstanceQuery) Svip(svip string) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        return instance.SecureVipAddress == svip
+    })
+}
+
+// Status 过滤指定状态(满足任一状态即可)
+func (query *InstanceQuery) Status(statuses ...meta.InstanceStatus) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        for _, status := range statuses {
+            if instance.Status == status {
+                return true
+            }
+        }
+        return false
+    })
+}
+
+// Zone 过滤指定zone(满足任一zone即可)
+func (query *InstanceQuery) Zone(zones ...string) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        for _, zone := range zones {
+            if instance.Zone == zone {
+                return true
+            }
+        }
+        return false
+    })
+}
+
+// MetadataExists 过滤包含指定元数据key的服务实例
+func (query *InstanceQuery) MetadataExists(key string) *InstanceQuery {
+    return query.Where(HasMetadata(key))
+}
+
+// MetadataEquals 过滤元数据key对应值与value相等的服务实例
+func (query *InstanceQuery) MetadataEquals(key, value string) *InstanceQuery {
+    return query.Where(MetadataEquals(key, value))
+}
+
+// MetadataPrefix 过滤元数据key对应值以prefix开头的服务实例
+func (query *InstanceQuery) MetadataPrefix(key, prefix string) *InstanceQuery {
+    return query.Where(MetadataHasPrefix(key, prefix))
+}
+
+// MetadataMatches 过滤元数据key对应值匹配正则表达式的服务实例
+func (query *InstanceQuery) MetadataMatches(key, pattern string) *InstanceQuery {
+    re, err := regexp.Compile(pattern)
+    if err != nil {
+        query.setErr(errors.New(fmt.Sprintf("metadata pattern is invalid, key: %s, error: %v", key, err)))
+        return query
+    }
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        v, ok := instance.Metadata[key]
+        return ok && re.MatchString(v)
+    })
+}
+
+// PortEnabled 过滤指定类型端口已启用的服务实例
+func (query *InstanceQuery) PortEnabled(portType meta.PortType) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        switch portType {
+        case meta.Secure:
+            return instance.SecurePort != nil && instance.SecurePort.IsEnabled()
+        case meta.UnSecure:
+            return instance.Port != nil && instance.Port.IsEnabled()
+        }
+        return false
+    })
+}
+
+// HostName 过滤主机名匹配正则表达式的服务实例
+func (query *InstanceQuery) HostName(pattern string) *InstanceQuery {
+    re, err := regexp.Compile(pattern)
+    if err != nil {
+        query.setErr(errors.New(fmt.Sprintf("hostname pattern is invalid, error: %v", err)))
+        return query
+    }
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        return re.MatchString(instance.HostName)
+    })
+}
+
+// MinLeaseAge 过滤注册时长不小于age的服务实例
+func (query *InstanceQuery) MinLeaseAge(age time.Duration) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        ts := registrationTimestamp(instance)
+        return ts > 0 && query.now().Sub(time.UnixMilli(ts)) >= age
+    })
+}
+
+// MaxLeaseAge 过滤注册时长不大于age的服务实例
+func (query *InstanceQuery) MaxLeaseAge(age time.Duration) *InstanceQuery {
+    return query.Where(func(instance *meta.InstanceInfo) bool {
+        ts := registrationTimestamp(instance)
+        return ts > 0 && query.now().Sub(time.UnixMilli(ts)) <= age
+    })
+}
+
+// OrderBy 指定结果排序比较函数
+func (query *InstanceQuery) OrderBy(comparator InstanceComparator) *InstanceQuery {
+    query.comparator = comparator
+    return query
+}
+
+// Limit 限制返回结果数量(小于等于0时不限制)
+func (query *InstanceQuery) Limit(limit int) *InstanceQuery {
+    query.limit = limit
+    return query
+}
+
+// List 执行查询, 返回满足条件的服务实例副本列表
+func (query *InstanceQuery) List() (instances []*meta.InstanceInfo, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            instances = nil
+            err = errors.New(fmt.Sprintf("InstanceQuery.List, recover error: %v", rc))
+        }
+    }()
+    if query.err != nil {
+        return nil, query.err
+    }
+    if _, err = query.discovery.isEnabled(); err != nil {
+        return nil, err
+    }
+    instances = make([]*meta.InstanceInfo, 0)
+    for _, apps := range query.discovery.Apps {
+        for _, app := range apps {
+            if app == nil {
+                continue
+            }
+            for _, instance := range app.Instances {
+                if instance != nil && query.test(instance) {
+                    instances = append(instances, instance.Copy())
+                }
+            }
+        }
+    }
+    if query.comparator != nil {
+        sort.SliceStable(instances, func(i, j int) bool {
+            return query.comparator(instances[i], instances[j])
+        })
+    }
+    if query.limit > 0 && len(instances) > query.limit {
+        instances = instances[:query.limit]
+    }
+    return instances, nil
+}
+
+// First 执行查询, 返回第一个满足条件的服务实例副本
+func (query *InstanceQuery) First() (*meta.InstanceInfo, error) {
+    instances, err := query.List()
+    if err != nil {
+        return nil, err
+    }
+    if len(instances) == 0 {
+        return nil, newNoAvailableError("no available service instance found")
+    }
+    return instances[0], nil
+}
+
+// Count 执行查询, 返回满足条件的服务实例数量
+func (query *InstanceQuery) Count() (int, error) {
+    instances, err := query.List()
+    if err != nil {
+        return 0, err
+    }
+    return len(instances), nil
+}
+
+// test 判断服务实例是否满足所有过滤条件
+func (query *InstanceQuery) test(instance *meta.InstanceInfo) bool {
+    for _, predicate := range query.predicates {
+        if !predicate(instance) {
+            return false
+        }
+    }
+    return true
+}
+
+// setErr 记录构造查询时的首个错误
+func (query *InstanceQuery) setErr(err error) {
+    if query.err == nil {
+        query.err = err
+    }
+}
+
+// registrationTimestamp 获取服务实例注册时间戳(毫秒)
+func registrationTimestamp(instance *meta.InstanceInfo) int64 {
+    if instance == nil || instance.LeaseInfo == nil {
+        return 0
+    }
+    return instance.LeaseInfo.RegistrationTimestamp
+}
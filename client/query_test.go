@@ -0,0 +1,109 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+// newTestQueryInstance 构造查询测试使用的服务实例
+func newTestQueryInstance(appName, instanceId, hostName, zone string, status meta.InstanceStatus, metadata map[string]string, registered time.Time) *meta.InstanceInfo {
+    return &meta.InstanceInfo{
+        InstanceId:       instanceId,
+        HostName:         hostName,
+        AppName:          appName,
+        Status:           status,
+        Port:             &meta.PortWrapper{Enabled: meta.StrTrue, Port: 8080},
+        SecurePort:       &meta.PortWrapper{Enabled: meta.StrFalse, Port: 8443},
+        LeaseInfo:        &meta.LeaseInfo{RegistrationTimestamp: registered.UnixMilli()},
+        Metadata:         metadata,
+        VipAddress:       appName,
+        SecureVipAddress: appName,
+        Zone:             zone,
+    }
+}
+
+// newTestDiscoveryClient 构造基于给定缓存的服务发现客户端
+func newTestDiscoveryClient(ast *assert.Assertions, apps map[string][]*meta.AppInfo) *DiscoveryClient {
+    config := &meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "query-test"},
+        ClientConfig:   &meta.ClientConfig{Zone: "zone1"},
+    }
+    ast.Nil(config.Check())
+    return &DiscoveryClient{
+        HttpClient: &HttpClient{},
+        Config:     config,
+        Apps:       apps,
+    }
+}
+
+func TestInstanceQuery(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    discovery := newTestDiscoveryClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {
+            {Name: "ORDER", Instances: []*meta.InstanceInfo{
+                newTestQueryInstance("ORDER", "order-1", "order-1.prod", "zone1", meta.StatusUp, map[string]string{"version": "1.2.0"}, now.Add(-2*time.Hour)),
+                newTestQueryInstance("ORDER", "order-2", "order-2.prod", "zone1", meta.StatusDown, map[string]string{"version": "2.0.1"}, now.Add(-time.Hour)),
+            }},
+        },
+        "zone2": {
+            {Name: "ORDER", Instances: []*meta.InstanceInfo{
+                newTestQueryInstance("ORDER", "order-3", "order-3.canary", "zone2", meta.StatusUp, map[string]string{"version": "2.1.0", "canary": "true"}, now.Add(-time.Minute)),
+            }},
+            {Name: "USER", Instances: []*meta.InstanceInfo{
+                newTestQueryInstance("USER", "user-1", "user-1.prod", "zone2", meta.StatusUp, nil, now.Add(-3*time.Hour)),
+            }},
+        },
+    })
+
+    instances, err := discovery.Query().App("order").OrderBy(CompareByInstanceId).List()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(3, len(instances))
+    ast.Equal("order-1", instances[0].InstanceId)
+
+    instances, err = discovery.Query().App("order").MetadataPrefix("version", "2.").Status(meta.StatusUp).List()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(1, len(instances))
+    ast.Equal("order-3", instances[0].InstanceId)
+
+    count, err := discovery.Query().MetadataMatches("version", `^\d+\.\d+\.0$`).Count()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(2, count)
+
+    count, err = discovery.Query().MetadataEquals("canary", "true").Zone("zone2").Count()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(1, count)
+
+    count, err = discovery.Query().MetadataExists("version").Count()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(3, count)
+
+    count, err = discovery.Query().HostName(`\.prod$`).PortEnabled(meta.UnSecure).Count()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(3, count)
+
+    count, err = discovery.Query().PortEnabled(meta.Secure).Count()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(0, count)
+
+    instance, err := discovery.Query().MinLeaseAge(30 * time.Minute).OrderBy(CompareByLeaseAge).First()
+    ast.Nilf(err, "%v", err)
+    ast.Equal("user-1", instance.InstanceId)
+
+    instances, err = discovery.Query().MaxLeaseAge(90 * time.Minute).OrderBy(CompareByInstanceId).Limit(1).List()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(1, len(instances))
+    ast.Equal("order-2", instances[0].InstanceId)
+
+    // 查询结果为副本, 修改不影响缓存
+    instances[0].Metadata["version"] = "changed"
+    ast.Equal("2.0.1", discovery.Apps["zone1"][0].Instances[1].Metadata["version"])
+
+    _, err = discovery.Query().MetadataMatches("version", "(").List()
+    ast.NotNil(err)
+
+    _, err = discovery.Query().App("missing").First()
+    ast.NotNil(err)
+}
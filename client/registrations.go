@@ -0,0 +1,327 @@
+package client
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/log"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// managedInstance 附加服务实例及其注册状态
+type managedInstance struct {
+    instance   *meta.InstanceInfo
+    registered bool
+}
+
+// RegistrationManager 附加服务实例注册管理(如: 同一进程以不同服务名暴露API端口及管理端口),
+// 与 EurekaClient 共用 HttpClient 及心跳间隔, 客户端启动后注册并定时心跳, 客户端关闭时取消注册
+type RegistrationManager struct {
+    client    *EurekaClient
+    Logger    log.Logger
+    instances map[string]*managedInstance
+    mutex     sync.Mutex
+    // 后台goroutine
+    routines sync.WaitGroup
+}
+
+// GetLogger 获取日志对象
+func (manager *RegistrationManager) GetLogger() log.Logger {
+    if manager.Logger == nil {
+        manager.Logger = log.DefaultLoggerImpl
+    }
+    return manager.Logger
+}
+
+// registrationKey 附加服务实例唯一标识
+func registrationKey(appName, instanceId string) string {
+    return strings.ToUpper(appName) + "/" + instanceId
+}
+
+// Register 添加并注册服务实例, 客户端未启动时在启动后注册; 注册失败时保留该服务实例, 并在下次心跳时重试
+func (manager *RegistrationManager) Register(instance *meta.InstanceInfo) *CommonResponse {
+    if instance == nil {
+        return &CommonResponse{Error: errors.New("InstanceInfo is nil")}
+    }
+    instance = instance.Copy()
+    if err := instance.CheckWithResolver(manager.client.config.HostInfoResolver); err != nil {
+        return &CommonResponse{Error: err}
+    }
+    instance.ActionType = meta.Added
+    key := registrationKey(instance.AppName, instance.InstanceId)
+    // 与主服务实例ID冲突(如: 均使用默认服务实例ID模板)时eureka server将视为同一服务实例
+    if key == registrationKey(manager.client.config.AppName, manager.client.config.InstanceId) {
+        return &CommonResponse{Error: errors.New("instance conflicts with the main instance: " + key)}
+    }
+    manager.mutex.Lock()
+    if manager.instances == nil {
+        manager.instances = make(map[string]*managedInstance)
+    }
+    if _, ok := manager.instances[key]; ok {
+        manager.mutex.Unlock()
+        return &CommonResponse{Error: errors.New("instance already exists: " + key)}
+    }
+    manager.instances[key] = &managedInstance{instance: instance}
+    manager.mutex.Unlock()
+    if !manager.client.State().IsRunning() {
+        return &CommonResponse{}
+    }
+    return manager.register(key)
+}
+
+// UnRegister 移除并取消注册服务实例
+func (manager *RegistrationManager) UnRegister(appName, instanceId string) *CommonResponse {
+    key := registrationKey(appName, instanceId)
+    manager.mutex.Lock()
+    managed, ok := manager.instances[key]
+    delete(manager.instances, key)
+    manager.mutex.Unlock()
+    if !ok {
+        return &CommonResponse{Error: errors.New("instance not found: " + key)}
+    }
+    if !managed.registered {
+        return &CommonResponse{}
+    }
+    server, err := manager.client.config.GetCurrZoneEurekaServer()
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    return manager.client.httpClient.UnRegister(server, managed.instance.AppName, managed.instance.InstanceId)
+}
+
+// ChangeStatus 变更服务实例状态(未注册时仅更新本地状态)
+func (manager *RegistrationManager) ChangeStatus(appName, instanceId string, status meta.InstanceStatus) *CommonResponse {
+    switch status {
+    case meta.StatusUp, meta.StatusDown, meta.StatusStarting, meta.StatusOutOfService, meta.StatusUnknown:
+    default:
+        return &CommonResponse{Error: errors.New("status value is invalid: " + string(status))}
+    }
+    return manager.modify(appName, instanceId, func(server *meta.EurekaServer, instance *meta.InstanceInfo) *CommonResponse {
+        return manager.client.httpClient.ChangeStatus(server, instance.AppName, instance.InstanceId, status)
+    }, func(instance *meta.InstanceInfo) {
+        instance.Status = status
+    })
+}
+
+// ChangeMetadata 变更服务实例元数据(未注册时仅更新本地元数据)
+func (manager *RegistrationManager) ChangeMetadata(appName, instanceId string, metadata map[string]string) *CommonResponse {
+    for key := range metadata {
+        if err := meta.ValidateMetadataKey(key); err != nil {
+            return &CommonResponse{Error: err}
+        }
+    }
+    return manager.modify(appName, instanceId, func(server *meta.EurekaServer, instance *meta.InstanceInfo) *CommonResponse {
+        return manager.client.httpClient.ModifyMetadata(server, instance.AppName, instance.InstanceId, metadata)
+    }, func(instance *meta.InstanceInfo) {
+        for key, value := range metadata {
+            instance.Metadata[key] = value
+        }
+    })
+}
+
+// Instance 查询服务实例信息(副本)
+func (manager *RegistrationManager) Instance(appName, instanceId string) *meta.InstanceInfo {
+    manager.mutex.Lock()
+    defer manager.mutex.Unlock()
+    if managed, ok := manager.instances[registrationKey(appName, instanceId)]; ok {
+        return managed.instance.Copy()
+    }
+    return nil
+}
+
+// Instances 查询所有服务实例信息(副本)
+func (manager *RegistrationManager) Instances() []*meta.InstanceInfo {
+    manager.mutex.Lock()
+    keys := make([]string, 0, len(manager.instances))
+    for key := range manager.instances {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    instances := make([]*meta.InstanceInfo, 0, len(keys))
+    for _, key := range keys {
+        instances = append(instances, manager.instances[key].instance.Copy())
+    }
+    manager.mutex.Unlock()
+    return instances
+}
+
+// IsRegistered 服务实例是否已注册
+func (manager *RegistrationManager) IsRegistered(appName, instanceId string) bool {
+    manager.mutex.Lock()
+    defer manager.mutex.Unlock()
+    managed, ok := manager.instances[registrationKey(appName, instanceId)]
+    return ok && managed.registered
+}
+
+// modify 变更服务实例信息, 已注册时先同步至eureka server, 成功后更新本地信息
+func (manager *RegistrationManager) modify(appName, instanceId string, remote func(server *meta.EurekaServer, instance *meta.InstanceInfo) *CommonResponse, local func(instance *meta.InstanceInfo)) *CommonResponse {
+    key := registrationKey(appName, instanceId)
+    manager.mutex.Lock()
+    managed, ok := manager.instances[key]
+    if !ok {
+        manager.mutex.Unlock()
+        return &CommonResponse{Error: errors.New("instance not found: " + key)}
+    }
+    registered, instance := managed.registered, managed.instance.Copy()
+    manager.mutex.Unlock()
+    response := &CommonResponse{}
+    if registered && manager.client.State().IsRunning() {
+        server, err := manager.client.config.GetCurrZoneEurekaServer()
+        if err != nil {
+            return &CommonResponse{Error: err}
+        }
+        if response = remote(server, instance); response.Error != nil {
+            return response
+        }
+    }
+    manager.mutex.Lock()
+    if managed, ok = manager.instances[key]; ok {
+        local(managed.instance)
+    }
+    manager.mutex.Unlock()
+    return response
+}
+
+// register 注册指定服务实例
+func (manager *RegistrationManager) register(key string) *CommonResponse {
+    manager.mutex.Lock()
+    managed, ok := manager.instances[key]
+    if !ok {
+        manager.mutex.Unlock()
+        return &CommonResponse{Error: errors.New("instance not found: " + key)}
+    }
+    instance := managed.instance.Copy()
+    manager.mutex.Unlock()
+    server, err := manager.client.config.GetCurrZoneEurekaServer()
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    response := manager.client.httpClient.Register(server, instance)
+    manager.mutex.Lock()
+    if managed, ok = manager.instances[key]; ok {
+        managed.registered = response.Error == nil
+    }
+    manager.mutex.Unlock()
+    if response.Error != nil {
+        manager.GetLogger().Errorf("RegistrationManager.register, failed to register %s, error: %v", key, response.Error)
+    }
+    return response
+}
+
+// start 注册所有服务实例并启动心跳
+func (manager *RegistrationManager) start(ctx context.Context) {
+    manager.beat0()
+    manager.routines.Add(1)
+    go manager.beat(ctx)
+}
+
+// beat 心跳处理
+func (manager *RegistrationManager) beat(ctx context.Context) {
+    defer manager.routines.Done()
+    ticker := time.NewTicker(time.Duration(manager.client.config.LeaseRenewalIntervalInSeconds) * time.Second)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            manager.beat0()
+        }
+    }
+}
+
+// beat0 未注册的服务实例重新注册, 已注册且状态为UP的服务实例发送心跳(eureka server返回404时重新注册)
+func (manager *RegistrationManager) beat0() {
+    defer func() {
+        if rc := recover(); rc != nil {
+            manager.GetLogger().Errorf("RegistrationManager.beat0, recover error: %v", rc)
+        }
+    }()
+    server, err := manager.client.config.GetCurrZoneEurekaServer()
+    if err != nil {
+        manager.GetLogger().Errorf("RegistrationManager.beat0, error: %v", err)
+        return
+    }
+    manager.mutex.Lock()
+    snapshot := make(map[string]managedInstance)
+    for key, managed := range manager.instances {
+        snapshot[key] = managedInstance{instance: managed.instance.Copy(), registered: managed.registered}
+    }
+    manager.mutex.Unlock()
+    for key, managed := range snapshot {
+        if !managed.registered {
+            manager.register(key)
+            continue
+        }
+        if managed.instance.Status != meta.StatusUp {
+            continue
+        }
+        response := manager.client.httpClient.Heartbeat(server, managed.instance.AppName, managed.instance.InstanceId)
+        if response.Error == nil {
+            continue
+        }
+        manager.GetLogger().Tracef("RegistrationManager.beat0, heartbeat failed: %s, error: %v", key, response.Error)
+        if errors.Is(response.Error, ErrInstanceNotFound) {
+            manager.register(key)
+        }
+    }
+}
+
+// wait 等待后台goroutine全部退出
+func (manager *RegistrationManager) wait() {
+    manager.routines.Wait()
+}
+
+// changeAllStatus 变更所有已注册服务实例在eureka server的状态(如: 优雅关闭时下线), 不变更本地状态, 客户端再次启动时按本地状态重新注册
+func (manager *RegistrationManager) changeAllStatus(status meta.InstanceStatus) {
+    server, err := manager.client.config.GetCurrZoneEurekaServer()
+    if err != nil {
+        manager.GetLogger().Warnf("RegistrationManager.changeAllStatus, error: %v", err)
+        return
+    }
+    for _, instance := range manager.Instances() {
+        if !manager.IsRegistered(instance.AppName, instance.InstanceId) {
+            continue
+        }
+        if response := manager.client.httpClient.ChangeStatus(server, instance.AppName, instance.InstanceId, status); response.Error != nil {
+            manager.GetLogger().Warnf("RegistrationManager.changeAllStatus, failed to change status of %s/%s, error: %v", instance.AppName, instance.InstanceId, response.Error)
+        }
+    }
+}
+
+// unRegisterAll 取消注册所有已注册服务实例(保留服务实例定义, 客户端再次启动时重新注册)
+func (manager *RegistrationManager) unRegisterAll() error {
+    server, err := manager.client.config.GetCurrZoneEurekaServer()
+    if err != nil {
+        return err
+    }
+    errs := make([]string, 0)
+    for _, instance := range manager.Instances() {
+        key := registrationKey(instance.AppName, instance.InstanceId)
+        manager.mutex.Lock()
+        managed, ok := manager.instances[key]
+        registered := ok && managed.registered
+        manager.mutex.Unlock()
+        if !registered {
+            continue
+        }
+        response := manager.client.httpClient.UnRegister(server, instance.AppName, instance.InstanceId)
+        if response.Error != nil {
+            errs = append(errs, fmt.Sprintf("%s: %v", key, response.Error))
+            continue
+        }
+        manager.mutex.Lock()
+        if managed, ok = manager.instances[key]; ok {
+            managed.registered = false
+        }
+        manager.mutex.Unlock()
+    }
+    if len(errs) > 0 {
+        return errors.New("failed to unregister instances: " + strings.Join(errs, "; "))
+    }
+    return nil
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+    "context"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestRegistrationManager(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:                       "main-app",
+            InstanceId:                    "127.0.0.1:28089",
+            NonSecurePort:                 28089,
+            LeaseRenewalIntervalInSeconds: 1,
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    manager := client.Registrations()
+
+    // 客户端启动前添加, 启动后注册
+    api := &meta.InstanceInfo{
+        AppName:    "api-app",
+        InstanceId: "127.0.0.1:28090",
+        Status:     meta.StatusUp,
+        Port:       &meta.PortWrapper{Enabled: meta.StrTrue, Port: 28090},
+        Metadata:   map[string]string{"kind": "api"},
+    }
+    ast.Nil(manager.Register(api).Error)
+    ast.NotNil(manager.Register(api).Error)
+    // 与主服务实例ID冲突
+    ast.NotNil(manager.Register(&meta.InstanceInfo{AppName: "main-app", InstanceId: "127.0.0.1:28089"}).Error)
+    ast.False(manager.IsRegistered("api-app", "127.0.0.1:28090"))
+    ast.Nil(server.Instance("api-app", "127.0.0.1:28090"))
+
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.NotNil(server.Instance("main-app", "127.0.0.1:28089"))
+    ast.NotNil(server.Instance("api-app", "127.0.0.1:28090"))
+    ast.True(manager.IsRegistered("api-app", "127.0.0.1:28090"))
+
+    // 客户端启动后添加, 立即注册
+    management := &meta.InstanceInfo{
+        AppName:    "management-app",
+        InstanceId: "127.0.0.1:28091",
+        Status:     meta.StatusUp,
+        Port:       &meta.PortWrapper{Enabled: meta.StrTrue, Port: 28091},
+    }
+    ast.Nil(manager.Register(management).Error)
+    ast.NotNil(server.Instance("management-app", "127.0.0.1:28091"))
+    ast.Len(manager.Instances(), 2)
+
+    // 各自独立的状态及元数据
+    ast.Nil(manager.ChangeStatus("api-app", "127.0.0.1:28090", meta.StatusOutOfService).Error)
+    ast.Equal(meta.StatusOutOfService, server.Instance("api-app", "127.0.0.1:28090").Status)
+    ast.Equal(meta.StatusUp, server.Instance("management-app", "127.0.0.1:28091").Status)
+    ast.Equal(meta.StatusOutOfService, manager.Instance("api-app", "127.0.0.1:28090").Status)
+    ast.NotNil(manager.ChangeStatus("api-app", "127.0.0.1:28090", "BAD").Error)
+    ast.Nil(manager.ChangeMetadata("management-app", "127.0.0.1:28091", map[string]string{"kind": "management"}).Error)
+    ast.Equal("management", manager.Instance("management-app", "127.0.0.1:28091").Metadata["kind"])
+    ast.Equal("api", manager.Instance("api-app", "127.0.0.1:28090").Metadata["kind"])
+    ast.NotNil(manager.ChangeStatus("missing", "missing", meta.StatusUp).Error)
+
+    // 服务实例被剔除后, 心跳返回404时重新注册
+    ast.Nil(client.HttpClient().SimpleUnRegister(server.URL, "management-app", "127.0.0.1:28091").Error)
+    ast.Nil(server.Instance("management-app", "127.0.0.1:28091"))
+    timeout := time.After(5 * time.Second)
+    for server.Instance("management-app", "127.0.0.1:28091") == nil {
+        select {
+        case <-timeout:
+            ast.FailNow("timed out waiting for re-registration")
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+    ast.Equal("management", server.Instance("management-app", "127.0.0.1:28091").Metadata["kind"])
+
+    // 取消注册
+    ast.Nil(manager.UnRegister("api-app", "127.0.0.1:28090").Error)
+    ast.Nil(server.Instance("api-app", "127.0.0.1:28090"))
+    ast.Nil(manager.Instance("api-app", "127.0.0.1:28090"))
+    ast.NotNil(manager.UnRegister("api-app", "127.0.0.1:28090").Error)
+
+    // 客户端关闭时取消注册, 再次启动时重新注册
+    response = client.Stop()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Nil(server.Instance("management-app", "127.0.0.1:28091"))
+    ast.False(manager.IsRegistered("management-app", "127.0.0.1:28091"))
+    ast.Len(manager.Instances(), 1)
+    response = client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.NotNil(server.Instance("management-app", "127.0.0.1:28091"))
+    ast.Nil(client.Stop().Error)
+}
+
+func TestRegistrationManager_GracefulStop(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "main-app", InstanceId: "main-1", NonSecurePort: 28092},
+        ClientConfig:   &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL, DiscoveryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    manager := client.Registrations()
+    ast.Nil(manager.Register(&meta.InstanceInfo{
+        AppName:    "api-app",
+        InstanceId: "api-1",
+        Status:     meta.StatusUp,
+        Port:       &meta.PortWrapper{Enabled: meta.StrTrue, Port: 28093},
+    }).Error)
+    ast.Nil(client.Start().Error)
+    ast.Equal(meta.StatusUp, server.Instance("api-app", "api-1").Status)
+
+    // 优雅关闭时附加服务实例在eureka server下线, 本地状态不变
+    var drainingStatus meta.InstanceStatus
+    response := client.GracefulStop(context.Background(), &GracefulStopOptions{
+        DrainPeriod: -1,
+        Drained: func() bool {
+            drainingStatus = server.Instance("api-app", "api-1").Status
+            return true
+        },
+    })
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(meta.StatusOutOfService, drainingStatus)
+    ast.Contains(server.Requests(), http.MethodPut+" /apps/api-app/api-1/status?value=OUT_OF_SERVICE")
+    ast.Nil(server.Instance("api-app", "api-1"))
+    ast.Equal(meta.StatusUp, manager.Instance("api-app", "api-1").Status)
+
+    // 再次启动时以UP状态重新注册
+    ast.Nil(client.Start().Error)
+    ast.Equal(meta.StatusUp, server.Instance("api-app", "api-1").Status)
+    ast.Nil(client.Stop().Error)
+}
+
+func TestRegistrationManager_HostInfoResolver(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv("TEST_REGISTRATION_HOSTNAME", "pod-1")
+    t.Setenv("TEST_REGISTRATION_IP_ADDRESS", "10.0.0.1")
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:          "main-app",
+            HostInfoResolver: &meta.NetworkHostInfoResolver{HostnameEnv: "TEST_REGISTRATION_HOSTNAME", IpAddressEnv: "TEST_REGISTRATION_IP_ADDRESS"},
+        },
+    })
+    ast.Nilf(err, "%v", err)
+
+    // 附加服务实例未设置主机名及IP地址时使用配置的主机信息解析
+    ast.Nil(client.Registrations().Register(&meta.InstanceInfo{AppName: "api-app", InstanceId: "api-1"}).Error)
+    instances := client.Registrations().Instances()
+    ast.Len(instances, 1)
+    ast.Equal("pod-1", instances[0].HostName)
+    ast.Equal("10.0.0.1", instances[0].IpAddr)
+}
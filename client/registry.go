@@ -6,6 +6,8 @@ import (
     "fmt"
     "github.com/jiashunx/eureka-client-go/log"
     "github.com/jiashunx/eureka-client-go/meta"
+    "sort"
+    "sync"
     "time"
 )
 
@@ -20,6 +22,12 @@ type RegistryClient struct {
     HeartbeatFunc func(*CommonResponse)
     // 服务实例状态, 仅当集成到 EurekaClient 时有效
     status meta.InstanceStatus
+    // 后台goroutine, 仅当集成到 EurekaClient 时有效
+    routines sync.WaitGroup
+    // 心跳后回调(同步执行), 仅当集成到 EurekaClient 时有效
+    onHeartbeat func(*CommonResponse)
+    // 服务注册及心跳结果记录, 仅当集成到 EurekaClient 时有效
+    health registryHealth
 }
 
 // GetLogger 获取客户端日志对象
@@ -49,6 +57,7 @@ func (registry *RegistryClient) start(ctx context.Context) (response *CommonResp
         registry.status = meta.StatusUp
     }
     registry.heartbeat = false
+    registry.routines.Add(1)
     go registry.beat(ctx)
     if _, err := registry.isEnabled(); err != nil {
         return &CommonResponse{Error: err}
@@ -60,25 +69,39 @@ func (registry *RegistryClient) start(ctx context.Context) (response *CommonResp
     }
     response = registry.HttpClient.Register(server, instance)
     registry.heartbeat = response.Error == nil
+    registry.health.onRegister(response)
     return response
 }
 
 // beat 心跳处理
 func (registry *RegistryClient) beat(ctx context.Context) {
+    defer registry.routines.Done()
     ticker := time.NewTicker(time.Duration(registry.Config.LeaseRenewalIntervalInSeconds) * time.Second)
-FL:
+    defer ticker.Stop()
     for {
         select {
         case <-ctx.Done():
-            ticker.Stop()
-            break FL
+            return
         default:
-            go registry.beat0(ctx)
+            registry.routines.Add(1)
+            go func() {
+                defer registry.routines.Done()
+                registry.beat0(ctx)
+            }()
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
         }
-        <-ticker.C
     }
 }
 
+// wait 等待后台goroutine全部退出
+func (registry *RegistryClient) wait() {
+    registry.routines.Wait()
+}
+
 // beat 心跳处理
 func (registry *RegistryClient) beat0(ctx context.Context) (response *CommonResponse) {
     defer func() {
@@ -92,6 +115,10 @@ func (registry *RegistryClient) beat0(ctx context.Context) (response *CommonResp
         if response.Error != nil {
             registry.GetLogger().Tracef("RegistryClient.beat0, OK")
         }
+        registry.health.onHeartbeat(response)
+        if registry.onHeartbeat != nil {
+            registry.onHeartbeat(response)
+        }
         if registry.HeartbeatFunc != nil {
             go registry.HeartbeatFunc(response)
         }
@@ -116,7 +143,18 @@ func (registry *RegistryClient) Register(status meta.InstanceStatus) *CommonResp
     if err != nil {
         return &CommonResponse{Error: err}
     }
-    return registry.HttpClient.Register(server, instance)
+    response := registry.HttpClient.Register(server, instance)
+    registry.health.onRegister(response)
+    return response
+}
+
+// ReRegister 使用当前服务状态重新注册服务实例, 注册成功后恢复心跳
+func (registry *RegistryClient) ReRegister() (response *CommonResponse) {
+    response = registry.Register(registry.status)
+    if response.Error == nil {
+        registry.heartbeat = true
+    }
+    return response
 }
 
 // Heartbeat 心跳
@@ -187,6 +225,81 @@ func (registry *RegistryClient) ChangeMetadata(metadata map[string]string) (resp
     return response
 }
 
+// SetMetadata 将元数据整体变更为metadata(差量更新): 仅发送新增或值变更的key; 存在需删除的key时(eureka server不支持删除元数据key),
+// 使用变更后的元数据重新注册服务实例. 变更成功后同步更新 Config.Metadata, 失败时 Config.Metadata 保持不变
+func (registry *RegistryClient) SetMetadata(metadata map[string]string) (response *CommonResponse) {
+    if _, err := registry.isEnabled(); err != nil {
+        return &CommonResponse{Error: err}
+    }
+    changed, deleted := diffMetadata(registry.Config.Metadata, metadata)
+    for key := range changed {
+        if err := meta.ValidateMetadataKey(key); err != nil {
+            return &CommonResponse{Error: err}
+        }
+    }
+    if len(changed) == 0 && len(deleted) == 0 {
+        return &CommonResponse{}
+    }
+    server, err := registry.Config.GetCurrZoneEurekaServer()
+    if err != nil {
+        return &CommonResponse{Error: err}
+    }
+    if len(deleted) == 0 {
+        response = registry.HttpClient.ModifyMetadata(server, registry.Config.AppName, registry.Config.InstanceId, changed)
+    } else {
+        var instance *meta.InstanceInfo
+        instance, err = registry.buildInstanceInfo(registry.status, meta.Added)
+        if err != nil {
+            return &CommonResponse{Error: err}
+        }
+        instance.Metadata = make(map[string]string)
+        for key, value := range metadata {
+            instance.Metadata[key] = value
+        }
+        response = registry.HttpClient.Register(server, instance)
+        registry.health.onRegister(response)
+    }
+    if response.Error == nil {
+        for key, value := range changed {
+            registry.Config.Metadata[key] = value
+        }
+        for _, key := range deleted {
+            delete(registry.Config.Metadata, key)
+        }
+    }
+    return response
+}
+
+// DeleteMetadata 删除元数据key(使用变更后的元数据重新注册服务实例)
+func (registry *RegistryClient) DeleteMetadata(keys ...string) *CommonResponse {
+    metadata := make(map[string]string)
+    for key, value := range registry.Config.Metadata {
+        metadata[key] = value
+    }
+    for _, key := range keys {
+        delete(metadata, key)
+    }
+    return registry.SetMetadata(metadata)
+}
+
+// diffMetadata 对比元数据, 返回新增或值变更的key及需删除的key
+func diffMetadata(current, target map[string]string) (map[string]string, []string) {
+    changed := make(map[string]string)
+    for key, value := range target {
+        if old, ok := current[key]; !ok || old != value {
+            changed[key] = value
+        }
+    }
+    deleted := make([]string, 0)
+    for key := range current {
+        if _, ok := target[key]; !ok {
+            deleted = append(deleted, key)
+        }
+    }
+    sort.Strings(deleted)
+    return changed, deleted
+}
+
 // isEnabled 服务注册功能是否开启
 func (registry *RegistryClient) isEnabled() (bool, error) {
     if !*registry.Config.RegistryEnabled {
@@ -208,7 +321,7 @@ func (registry *RegistryClient) buildInstanceInfo(status meta.InstanceStatus, ac
         Port:                          meta.DefaultNonSecurePortWrapper(),
         SecurePort:                    meta.DefaultSecurePortWrapper(),
         CountryId:                     1,
-        DataCenterInfo:                meta.DefaultDataCenterInfo(),
+        DataCenterInfo:                Config.DataCenterInfo.Copy(),
         LeaseInfo:                     meta.DefaultLeaseInfo(),
         Metadata:                      make(map[string]string),
         HomePageUrl:                   Config.HomePageUrl,
@@ -223,6 +336,9 @@ func (registry *RegistryClient) buildInstanceInfo(status meta.InstanceStatus, ac
         Region:                        Config.Region,
         Zone:                          Config.Zone,
     }
+    if instance.DataCenterInfo == nil {
+        instance.DataCenterInfo = meta.DefaultDataCenterInfo()
+    }
     if *Config.PreferIpAddress {
         instance.HostName = Config.IpAddress
     }
@@ -0,0 +1,107 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "testing"
+)
+
+func TestRegistryClient_SetMetadata(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:       "registry-test",
+            InstanceId:    "127.0.0.1:28092",
+            NonSecurePort: 28092,
+            Metadata:      map[string]string{"version": "v1", "zone": "zone1", "weight": "10"},
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+            DiscoveryEnabled:        &meta.False,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    defer client.Stop()
+    registry := client.registryClient
+    instance := func() *meta.InstanceInfo {
+        return server.Instance("registry-test", "127.0.0.1:28092")
+    }
+    requests := func() int {
+        return len(server.Requests())
+    }
+
+    // 仅发送新增或变更的key
+    count := requests()
+    response = client.SetMetadata(map[string]string{"version": "v2", "zone": "zone1", "weight": "10", "owner": "a b"})
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(count+1, requests())
+    ast.Equal("PUT /apps/registry-test/127.0.0.1:28092/metadata?owner=a+b&version=v2", server.Requests()[count])
+    ast.Equal(map[string]string{"version": "v2", "zone": "zone1", "weight": "10", "owner": "a b"}, registry.Config.Metadata)
+    ast.Equal("a b", instance().Metadata["owner"])
+
+    // 无变更时不发送请求
+    count = requests()
+    ast.Nil(client.SetMetadata(map[string]string{"version": "v2", "zone": "zone1", "weight": "10", "owner": "a b"}).Error)
+    ast.Equal(count, requests())
+
+    // 删除key时重新注册
+    count = requests()
+    response = client.SetMetadata(map[string]string{"version": "v3", "zone": "zone1", "owner": "a b"})
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(count+1, requests())
+    ast.Equal("POST /apps/registry-test", server.Requests()[count])
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1", "owner": "a b"}, registry.Config.Metadata)
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1", "owner": "a b"}, instance().Metadata)
+    ast.Equal(meta.StatusUp, instance().Status)
+    ast.Nil(client.DeleteMetadata("owner", "missing").Error)
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1"}, instance().Metadata)
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1"}, registry.Config.Metadata)
+
+    // 非法key
+    count = requests()
+    ast.NotNil(client.SetMetadata(map[string]string{"version": "v3", "bad key": "x"}).Error)
+    ast.Equal(count, requests())
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1"}, registry.Config.Metadata)
+
+    // 失败时本地元数据保持不变
+    server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+        w.WriteHeader(http.StatusInternalServerError)
+        return true
+    })
+    ast.NotNil(client.SetMetadata(map[string]string{"version": "v4", "zone": "zone1"}).Error)
+    ast.NotNil(client.DeleteMetadata("zone").Error)
+    ast.Equal(map[string]string{"version": "v3", "zone": "zone1"}, registry.Config.Metadata)
+    server.Intercept(nil)
+}
+
+func TestRegistryClient_AmazonDataCenterInfo(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+    info := &meta.AmazonInfo{InstanceId: "i-1", AvailabilityZone: "us-east-1a", LocalIpv4: "10.0.0.1"}
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:        "amazon-test",
+            InstanceId:     "i-1",
+            DataCenterInfo: meta.AmazonDataCenterInfo(info),
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+            DiscoveryEnabled:        &meta.False,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    defer client.Stop()
+    // 注册AWS数据中心信息
+    registered := server.Instance("amazon-test", "i-1")
+    ast.True(registered.DataCenterInfo.IsAmazon())
+    ast.Equal(meta.AmazonDataCenterClass, registered.DataCenterInfo.Class)
+    ast.Equal(info, registered.DataCenterInfo.Metadata)
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+    "errors"
+    "net/http"
+    "time"
+)
+
+var (
+    // DefaultRetryMaxAttempts 默认同一服务地址最大尝试次数(含首次请求)
+    DefaultRetryMaxAttempts       = 3
+    // DefaultRetryInitialBackoff 默认首次重试退避时长
+    DefaultRetryInitialBackoff    = 100 * time.Millisecond
+    // DefaultRetryMaxBackoff 默认最大退避时长
+    DefaultRetryMaxBackoff        = 2 * time.Second
+    // DefaultRetryBackoffMultiplier 默认退避时长增长倍数
+    DefaultRetryBackoffMultiplier = 2.0
+    // DefaultRetryableStatusCodes 默认可重试的响应状态码
+    DefaultRetryableStatusCodes   = []int{502, 503, 504}
+    // DefaultRetryableErrors 默认可重试的错误
+    DefaultRetryableErrors        = []error{ErrServerUnavailable}
+)
+
+// RetryPolicy HttpClient与eureka server通讯重试策略:
+// 同一eureka server服务地址可重试的失败按退避时长重试, 达到最大尝试次数后尝试下一个服务地址;
+// 不可重试的失败不再重试同一服务地址: 明确的客户端错误(4xx, 如: 400, 404)不再尝试其他服务地址, 其他失败(如: 500)仍尝试下一个服务地址
+type RetryPolicy struct {
+    // 同一eureka server服务地址最大尝试次数(含首次请求), 默认: DefaultRetryMaxAttempts
+    MaxAttempts int
+    // 单次请求超时时长(含读取响应内容), 小于等于0时仅使用 meta.EurekaServer 配置的超时时长
+    PerAttemptTimeout time.Duration
+    // 可重试的http响应码, 默认: DefaultRetryableStatusCodes
+    RetryableStatusCodes []int
+    // 未收到http响应时可重试的错误分类(通过 errors.Is 判断), 默认: DefaultRetryableErrors
+    RetryableErrors []error
+    // 首次重试前的退避时长, 默认: DefaultRetryInitialBackoff, 小于0时不退避
+    InitialBackoff time.Duration
+    // 最大退避时长, 默认: DefaultRetryMaxBackoff
+    MaxBackoff time.Duration
+    // 退避时长倍数, 默认: DefaultRetryBackoffMultiplier
+    BackoffMultiplier float64
+}
+
+// maxAttempts 同一eureka server服务地址最大尝试次数
+func (policy *RetryPolicy) maxAttempts() int {
+    if policy.MaxAttempts <= 0 {
+        return DefaultRetryMaxAttempts
+    }
+    return policy.MaxAttempts
+}
+
+// backoff 第attempt次尝试失败后的退避时长
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+    initial, max, multiplier := policy.InitialBackoff, policy.MaxBackoff, policy.BackoffMultiplier
+    if initial < 0 {
+        return 0
+    }
+    if initial == 0 {
+        initial = DefaultRetryInitialBackoff
+    }
+    if max <= 0 {
+        max = DefaultRetryMaxBackoff
+    }
+    if multiplier < 1 {
+        multiplier = DefaultRetryBackoffMultiplier
+    }
+    duration := float64(initial)
+    for i := 1; i < attempt && duration < float64(max); i++ {
+        duration *= multiplier
+    }
+    if duration > float64(max) {
+        return max
+    }
+    return time.Duration(duration)
+}
+
+// retryable 通讯失败是否可重试: http响应码不符合预期时根据响应码判断, 其他失败(如: 连接失败、超时、读取响应失败)根据错误分类判断
+func (policy *RetryPolicy) retryable(expect int, response *EurekaResponse) bool {
+    if response.Error == nil {
+        return false
+    }
+    if response.HttpResponse != nil && response.HttpResponse.StatusCode != expect {
+        statusCodes := policy.RetryableStatusCodes
+        if statusCodes == nil {
+            statusCodes = DefaultRetryableStatusCodes
+        }
+        for _, statusCode := range statusCodes {
+            if response.HttpResponse.StatusCode == statusCode {
+                return true
+            }
+        }
+        return false
+    }
+    retryableErrors := policy.RetryableErrors
+    if retryableErrors == nil {
+        retryableErrors = DefaultRetryableErrors
+    }
+    for _, target := range retryableErrors {
+        if errors.Is(response.Error, target) {
+            return true
+        }
+    }
+    return false
+}
+
+// isClientError 通讯失败是否为明确的客户端错误(http响应码为4xx), 此类失败不再尝试其他eureka server服务地址
+func isClientError(response *EurekaResponse) bool {
+    if response.Error == nil || response.HttpResponse == nil {
+        return false
+    }
+    statusCode := response.HttpResponse.StatusCode
+    return statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// newTestRetryServer 前failures次请求返回statusCode, 之后返回200
+func newTestRetryServer(failures int32, statusCode int) (*httptest.Server, *int32) {
+    var count int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&count, 1) <= failures {
+            w.WriteHeader(statusCode)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    return server, &count
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+    ast := assert.New(t)
+    policy := &RetryPolicy{}
+    ast.Equal(DefaultRetryMaxAttempts, policy.maxAttempts())
+    ast.Equal(DefaultRetryInitialBackoff, policy.backoff(1))
+    ast.Equal(2*DefaultRetryInitialBackoff, policy.backoff(2))
+    ast.Equal(DefaultRetryMaxBackoff, policy.backoff(100))
+    policy = &RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, BackoffMultiplier: 3}
+    ast.Equal(5, policy.maxAttempts())
+    ast.Equal(30*time.Millisecond, policy.backoff(2))
+    ast.Equal(50*time.Millisecond, policy.backoff(3))
+    ast.Equal(time.Duration(0), (&RetryPolicy{InitialBackoff: -1}).backoff(3))
+}
+
+func TestHttpClient_RetryPolicy(t *testing.T) {
+    ast := assert.New(t)
+    client := &HttpClient{RetryPolicy: &RetryPolicy{InitialBackoff: -1}}
+
+    // 503可重试, 同一服务地址重试直至成功
+    server, count := newTestRetryServer(2, http.StatusServiceUnavailable)
+    defer server.Close()
+    response := client.SimpleHeartbeat(server.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(3), atomic.LoadInt32(count))
+    ast.Len(response.Response.Responses, 3)
+    for idx, r := range response.Response.Responses {
+        ast.Equal(idx+1, r.Attempt)
+        ast.Equal(server.URL, r.Request.ServiceUrl)
+    }
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrServerUnavailable))
+
+    // 达到最大尝试次数后尝试下一个服务地址
+    failing, failingCount := newTestRetryServer(100, http.StatusBadGateway)
+    defer failing.Close()
+    backup, backupCount := newTestRetryServer(0, http.StatusOK)
+    defer backup.Close()
+    response = client.SimpleHeartbeat(failing.URL+","+backup.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(DefaultRetryMaxAttempts), atomic.LoadInt32(failingCount))
+    ast.Equal(int32(1), atomic.LoadInt32(backupCount))
+    ast.Len(response.Response.Responses, DefaultRetryMaxAttempts+1)
+    ast.Equal(1, response.Response.Attempt)
+    ast.Equal(backup.URL, response.Response.Request.ServiceUrl)
+
+    // 连接失败可重试
+    closedUrl := newTestClosedUrl(ast)
+    response = client.SimpleHeartbeat(closedUrl+","+backup.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Len(response.Response.Responses, DefaultRetryMaxAttempts+1)
+
+    // 404不可重试, 也不再尝试其他服务地址
+    notFound, notFoundCount := newTestRetryServer(100, http.StatusNotFound)
+    defer notFound.Close()
+    atomic.StoreInt32(backupCount, 0)
+    response = client.SimpleHeartbeat(notFound.URL+","+backup.URL, "APP", "instance-1")
+    ast.True(errors.Is(response.Error, ErrInstanceNotFound))
+    ast.Equal(int32(1), atomic.LoadInt32(notFoundCount))
+    ast.Equal(int32(0), atomic.LoadInt32(backupCount))
+    ast.Len(response.Response.Responses, 1)
+
+    // 500不可重试, 但仍尝试下一个服务地址
+    internal, internalCount := newTestRetryServer(100, http.StatusInternalServerError)
+    defer internal.Close()
+    atomic.StoreInt32(backupCount, 0)
+    response = client.SimpleHeartbeat(internal.URL+","+backup.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(1), atomic.LoadInt32(internalCount))
+    ast.Equal(int32(1), atomic.LoadInt32(backupCount))
+    ast.Len(response.Response.Responses, 2)
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrServerUnavailable))
+
+    // 自定义可重试响应码及错误分类
+    client.RetryPolicy = &RetryPolicy{MaxAttempts: 2, InitialBackoff: -1, RetryableStatusCodes: []int{http.StatusNotFound}, RetryableErrors: []error{}}
+    atomic.StoreInt32(notFoundCount, 0)
+    response = client.SimpleHeartbeat(notFound.URL, "APP", "instance-1")
+    ast.True(errors.Is(response.Error, ErrInstanceNotFound))
+    ast.Equal(int32(2), atomic.LoadInt32(notFoundCount))
+    // 连接失败不可重试时不再重试同一服务地址, 但仍尝试下一个服务地址
+    response = client.SimpleHeartbeat(closedUrl+","+backup.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Len(response.Response.Responses, 2)
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrServerUnavailable))
+    ast.Equal(backup.URL, response.Response.Request.ServiceUrl)
+
+    // 未配置重试策略时每个服务地址仅请求一次, 失败后尝试下一个服务地址
+    client.RetryPolicy = nil
+    atomic.StoreInt32(notFoundCount, 0)
+    atomic.StoreInt32(backupCount, 0)
+    response = client.SimpleHeartbeat(notFound.URL+","+backup.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Equal(int32(1), atomic.LoadInt32(notFoundCount))
+    ast.Equal(int32(1), atomic.LoadInt32(backupCount))
+}
+
+func TestHttpClient_RetryPolicyPerAttemptTimeout(t *testing.T) {
+    ast := assert.New(t)
+    var count int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if atomic.AddInt32(&count, 1) == 1 {
+            select {
+            case <-r.Context().Done():
+            case <-time.After(2 * time.Second):
+            }
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer server.Close()
+    client := &HttpClient{RetryPolicy: &RetryPolicy{PerAttemptTimeout: 100 * time.Millisecond, InitialBackoff: -1}}
+    start := time.Now()
+    response := client.SimpleHeartbeat(server.URL, "APP", "instance-1")
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.Less(time.Since(start), time.Second)
+    ast.Len(response.Response.Responses, 2)
+    ast.True(errors.Is(response.Response.Responses[0].Error, ErrServerUnavailable))
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "math/rand"
+    "strings"
+    "sync"
+)
+
+// DefaultRouteName 默认服务实例池路由名称
+const DefaultRouteName = "default"
+
+// MetadataRoute 基于服务实例元数据的路由规则
+type MetadataRoute struct {
+    // 路由名称(用于日志及结果标识)
+    Name string
+    // 服务实例匹配条件
+    Match InstancePredicate
+    // 路由流量百分比, 取值范围: [0, 100]
+    Percent float64
+}
+
+// Router 基于元数据的服务实例路由(灰度/版本路由)
+type Router struct {
+    discovery *DiscoveryClient
+    // 服务名称(大写)与路由规则映射
+    routes map[string][]*MetadataRoute
+    mutex  sync.RWMutex
+    // 随机数生成函数, 返回值范围: [0, 100)
+    random func() float64
+}
+
+// NewRouter 根据 *DiscoveryClient 创建服务实例路由
+func NewRouter(discovery *DiscoveryClient) *Router {
+    return &Router{
+        discovery: discovery,
+        routes:    make(map[string][]*MetadataRoute),
+        random: func() float64 {
+            return rand.Float64() * 100
+        },
+    }
+}
+
+// SetRoutes 设置指定服务的路由规则(覆盖已有规则)
+func (router *Router) SetRoutes(appName string, routes ...*MetadataRoute) error {
+    if strings.TrimSpace(appName) == "" {
+        return errors.New("appName is empty")
+    }
+    total := float64(0)
+    for idx, route := range routes {
+        if route == nil {
+            return errors.New(fmt.Sprintf("route is nil, idx: %d", idx))
+        }
+        if route.Match == nil {
+            return errors.New(fmt.Sprintf("route match predicate is nil, idx: %d", idx))
+        }
+        if route.Percent < 0 || route.Percent > 100 {
+            return errors.New(fmt.Sprintf("route percent is invalid, idx: %d, percent: %v", idx, route.Percent))
+        }
+        total += route.Percent
+    }
+    if total > 100 {
+        return errors.New(fmt.Sprintf("the sum of route percents exceeds 100: %v", total))
+    }
+    router.mutex.Lock()
+    defer router.mutex.Unlock()
+    router.routes[strings.ToUpper(appName)] = routes
+    return nil
+}
+
+// RemoveRoutes 删除指定服务的路由规则
+func (router *Router) RemoveRoutes(appName string) {
+    router.mutex.Lock()
+    defer router.mutex.Unlock()
+    delete(router.routes, strings.ToUpper(appName))
+}
+
+// GetRoutes 获取指定服务的路由规则
+func (router *Router) GetRoutes(appName string) []*MetadataRoute {
+    router.mutex.RLock()
+    defer router.mutex.RUnlock()
+    return router.routes[strings.ToUpper(appName)]
+}
+
+// SelectInstances 根据路由规则选择服务实例列表, 返回命中的路由名称
+func (router *Router) SelectInstances(appName string) (instances []*meta.InstanceInfo, routeName string, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            instances, routeName = nil, ""
+            err = errors.New(fmt.Sprintf("Router.SelectInstances, recover error: %v", rc))
+        }
+        if err != nil {
+            router.discovery.GetLogger().Tracef("Router.SelectInstances, FAILED >>> appName: %s, error: %v", appName, err)
+        }
+        if err == nil {
+            router.discovery.GetLogger().Tracef("Router.SelectInstances, OK >>> appName: %s, route: %s, ret: %v", appName, routeName, SummaryInstances(instances))
+        }
+    }()
+    app, err := router.discovery.FilterApp(router.discovery.Apps, appName)
+    if err != nil {
+        return nil, "", err
+    }
+    routes := router.GetRoutes(appName)
+    if len(routes) > 0 {
+        roll, cumulative := router.random(), float64(0)
+        for _, route := range routes {
+            cumulative += route.Percent
+            if roll >= cumulative {
+                continue
+            }
+            subset := filterInstances(app.Instances, route.Match)
+            if len(subset) > 0 {
+                return subset, route.Name, nil
+            }
+            break
+        }
+    }
+    return router.defaultPool(app.Instances, routes), DefaultRouteName, nil
+}
+
+// Select 根据路由规则选择服务实例（随机选择）
+func (router *Router) Select(appName string) (*meta.InstanceInfo, error) {
+    instances, _, err := router.SelectInstances(appName)
+    if err != nil {
+        return nil, err
+    }
+    return instances[rand.Intn(len(instances))], nil
+}
+
+// defaultPool 默认服务实例池: 不匹配任何路由规则的服务实例, 若为空则返回全部服务实例
+func (router *Router) defaultPool(instances []*meta.InstanceInfo, routes []*MetadataRoute) []*meta.InstanceInfo {
+    pool := filterInstances(instances, func(instance *meta.InstanceInfo) bool {
+        for _, route := range routes {
+            if route.Match(instance) {
+                return false
+            }
+        }
+        return true
+    })
+    if len(pool) == 0 {
+        return instances
+    }
+    return pool
+}
+
+// filterInstances 过滤满足条件的服务实例
+func filterInstances(instances []*meta.InstanceInfo, predicate InstancePredicate) []*meta.InstanceInfo {
+    filtered := make([]*meta.InstanceInfo, 0)
+    for _, instance := range instances {
+        if instance != nil && predicate(instance) {
+            filtered = append(filtered, instance)
+        }
+    }
+    return filtered
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestRouter_SelectInstances(t *testing.T) {
+    ast := assert.New(t)
+    now := time.Now()
+    discovery := newTestDiscoveryClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {
+            {Name: "ORDER", Instances: []*meta.InstanceInfo{
+                newTestQueryInstance("ORDER", "order-1", "order-1", "zone1", meta.StatusUp, map[string]string{"version": "1.0.0"}, now),
+                newTestQueryInstance("ORDER", "order-2", "order-2", "zone1", meta.StatusUp, map[string]string{"version": "1.0.0"}, now),
+                newTestQueryInstance("ORDER", "order-3", "order-3", "zone1", meta.StatusUp, map[string]string{"version": "2.1.0"}, now),
+                newTestQueryInstance("ORDER", "order-4", "order-4", "zone1", meta.StatusDown, map[string]string{"canary": "true"}, now),
+            }},
+        },
+    })
+    router := NewRouter(discovery)
+    err := router.SetRoutes("order",
+        &MetadataRoute{Name: "v2", Match: MetadataHasPrefix("version", "2."), Percent: 5},
+        &MetadataRoute{Name: "canary", Match: MetadataEquals("canary", "true"), Percent: 10},
+    )
+    ast.Nilf(err, "%v", err)
+
+    // 命中v2路由
+    router.random = func() float64 { return 3 }
+    instances, route, err := router.SelectInstances("ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Equal("v2", route)
+    ast.Equal(1, len(instances))
+    ast.Equal("order-3", instances[0].InstanceId)
+
+    // 命中canary路由, 但无可用实例, 回退至默认实例池
+    router.random = func() float64 { return 12 }
+    instances, route, err = router.SelectInstances("ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Equal(DefaultRouteName, route)
+    ast.Equal(2, len(instances))
+
+    // 未命中任何路由, 默认实例池不包含v2实例
+    router.random = func() float64 { return 50 }
+    for i := 0; i < 10; i++ {
+        instance, err := router.Select("ORDER")
+        ast.Nilf(err, "%v", err)
+        ast.Equal("1.0.0", instance.Metadata["version"])
+    }
+
+    // 无路由规则时选择全部可用实例
+    router.RemoveRoutes("ORDER")
+    instances, route, err = router.SelectInstances("ORDER")
+    ast.Nilf(err, "%v", err)
+    ast.Equal(DefaultRouteName, route)
+    ast.Equal(3, len(instances))
+
+    _, _, err = router.SelectInstances("USER")
+    ast.NotNil(err)
+}
+
+func TestRouter_SetRoutes(t *testing.T) {
+    ast := assert.New(t)
+    router := NewRouter(newTestDiscoveryClient(ast, map[string][]*meta.AppInfo{}))
+    ast.NotNil(router.SetRoutes("", &MetadataRoute{Match: HasMetadata("version"), Percent: 10}))
+    ast.NotNil(router.SetRoutes("order", &MetadataRoute{Percent: 10}))
+    ast.NotNil(router.SetRoutes("order", &MetadataRoute{Match: HasMetadata("version"), Percent: 101}))
+    ast.NotNil(router.SetRoutes("order",
+        &MetadataRoute{Match: HasMetadata("version"), Percent: 60},
+        &MetadataRoute{Match: HasMetadata("canary"), Percent: 50},
+    ))
+    ast.Nil(router.SetRoutes("order", &MetadataRoute{Match: AllOf(HasMetadata("version"), HasMetadata("canary")), Percent: 100}))
+    ast.Equal(1, len(router.GetRoutes("ORDER")))
+}
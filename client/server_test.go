@@ -0,0 +1,259 @@
+package client
+
+import (
+    "encoding/json"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+)
+
+// testEurekaServer 测试使用的内存版eureka server
+type testEurekaServer struct {
+    *httptest.Server
+    mutex     sync.Mutex
+    apps      map[string]map[string]*meta.InstanceInfo
+    overrides map[string]meta.InstanceStatus
+    asgs      map[string]meta.AsgStatus
+    requests  []string
+    intercept func(w http.ResponseWriter, r *http.Request) bool
+}
+
+// newTestEurekaServer 创建并启动测试使用的内存版eureka server
+func newTestEurekaServer() *testEurekaServer {
+    server := &testEurekaServer{
+        apps:      make(map[string]map[string]*meta.InstanceInfo),
+        overrides: make(map[string]meta.InstanceStatus),
+        asgs:      make(map[string]meta.AsgStatus),
+    }
+    server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+    return server
+}
+
+// Requests 获取已接收的请求列表(格式: METHOD URI)
+func (server *testEurekaServer) Requests() []string {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return append(make([]string, 0), server.requests...)
+}
+
+// Intercept 设置请求拦截处理(返回true时不再执行默认处理)
+func (server *testEurekaServer) Intercept(intercept func(w http.ResponseWriter, r *http.Request) bool) {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    server.intercept = intercept
+}
+
+// Instance 获取已注册服务实例
+func (server *testEurekaServer) Instance(appName, instanceId string) *meta.InstanceInfo {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    if instances, ok := server.apps[strings.ToUpper(appName)]; ok {
+        return instances[instanceId].Copy()
+    }
+    return nil
+}
+
+// AsgStatus 获取ASG状态
+func (server *testEurekaServer) AsgStatus(asgName string) meta.AsgStatus {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return server.asgs[asgName]
+}
+
+// Put 直接写入服务实例
+func (server *testEurekaServer) Put(instance *meta.InstanceInfo) {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    appName := strings.ToUpper(instance.AppName)
+    if _, ok := server.apps[appName]; !ok {
+        server.apps[appName] = make(map[string]*meta.InstanceInfo)
+    }
+    server.apps[appName][instance.InstanceId] = instance.Copy()
+}
+
+// serve 处理eureka server请求
+func (server *testEurekaServer) serve(w http.ResponseWriter, r *http.Request) {
+    server.mutex.Lock()
+    server.requests = append(server.requests, r.Method+" "+r.URL.RequestURI())
+    intercept := server.intercept
+    server.mutex.Unlock()
+    if intercept != nil && intercept(w, r) {
+        return
+    }
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    paths := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+    switch {
+    case r.Method == http.MethodPost && len(paths) == 2 && paths[0] == "apps":
+        body := make(map[string]*meta.InstanceInfo)
+        if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["instance"] == nil {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        appName := strings.ToUpper(paths[1])
+        if _, ok := server.apps[appName]; !ok {
+            server.apps[appName] = make(map[string]*meta.InstanceInfo)
+        }
+        server.apps[appName][body["instance"].InstanceId] = body["instance"]
+        w.WriteHeader(http.StatusNoContent)
+    case r.Method == http.MethodGet && len(paths) == 1 && paths[0] == "apps":
+        server.writeApps(w, func(instance *meta.InstanceInfo) bool { return true })
+    case r.Method == http.MethodGet && len(paths) == 2 && paths[0] == "apps" && paths[1] == "delta":
+        server.writeApps(w, func(instance *meta.InstanceInfo) bool { return true })
+    case r.Method == http.MethodGet && len(paths) == 1 && paths[0] == "status":
+        server.writeJson(w, map[string]interface{}{
+            "generalStats":     map[string]string{"environment": "test", "num-of-cpus": "1"},
+            "applicationStats": map[string]string{"registered-replicas": "", "available-replicas": ""},
+            "instanceInfo":     &meta.InstanceInfo{AppName: "EUREKA", InstanceId: "eureka-1", Status: meta.StatusUp},
+        })
+    case r.Method == http.MethodGet && len(paths) == 2 && paths[0] == "serverinfo" && paths[1] == "statusoverrides":
+        server.writeJson(w, server.overrides)
+    case r.Method == http.MethodPut && len(paths) == 3 && paths[0] == "asg" && paths[2] == "status":
+        value := meta.AsgStatus(r.URL.Query().Get("value"))
+        if value != meta.AsgEnabled && value != meta.AsgDisabled {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        server.asgs[paths[1]] = value
+        w.WriteHeader(http.StatusOK)
+    case r.Method == http.MethodPost && len(paths) == 2 && paths[0] == "peerreplication" && paths[1] == "batch":
+        body := make(map[string][]*meta.ReplicationInstance)
+        if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        results := make([]*meta.ReplicationInstanceResponse, 0)
+        for _, replication := range body["replicationList"] {
+            results = append(results, server.replicate(replication))
+        }
+        server.writeJson(w, map[string]interface{}{"responseList": results})
+    case r.Method == http.MethodGet && len(paths) == 2 && (paths[0] == "vips" || paths[0] == "svips"):
+        server.writeApps(w, func(instance *meta.InstanceInfo) bool {
+            if paths[0] == "vips" {
+                return instance.VipAddress == paths[1]
+            }
+            return instance.SecureVipAddress == paths[1]
+        })
+    case r.Method == http.MethodGet && len(paths) == 2 && paths[0] == "apps":
+        instances, ok := server.apps[strings.ToUpper(paths[1])]
+        if !ok {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        list := make([]*meta.InstanceInfo, 0)
+        for _, instance := range instances {
+            list = append(list, instance)
+        }
+        server.writeJson(w, map[string]interface{}{"application": map[string]interface{}{"name": strings.ToUpper(paths[1]), "instance": list}})
+    case r.Method == http.MethodGet && len(paths) == 2 && paths[0] == "instances":
+        for _, instances := range server.apps {
+            if instance, ok := instances[paths[1]]; ok {
+                server.writeJson(w, map[string]interface{}{"instance": instance})
+                return
+            }
+        }
+        w.WriteHeader(http.StatusNotFound)
+    case len(paths) >= 3 && paths[0] == "apps":
+        instance := server.apps[strings.ToUpper(paths[1])][paths[2]]
+        if instance == nil {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        switch {
+        case r.Method == http.MethodGet && len(paths) == 3:
+            server.writeJson(w, map[string]interface{}{"instance": instance})
+        case r.Method == http.MethodPut && len(paths) == 3:
+            w.WriteHeader(http.StatusOK)
+        case r.Method == http.MethodDelete && len(paths) == 3:
+            delete(server.apps[strings.ToUpper(paths[1])], paths[2])
+            w.WriteHeader(http.StatusOK)
+        case r.Method == http.MethodPut && len(paths) == 4 && paths[3] == "status":
+            instance.Status = meta.InstanceStatus(r.URL.Query().Get("value"))
+            server.overrides[instance.InstanceId] = instance.Status
+            w.WriteHeader(http.StatusOK)
+        case r.Method == http.MethodDelete && len(paths) == 4 && paths[3] == "status":
+            delete(server.overrides, instance.InstanceId)
+            instance.Status = meta.StatusUp
+            if value := r.URL.Query().Get("value"); value != "" {
+                instance.Status = meta.InstanceStatus(value)
+            }
+            w.WriteHeader(http.StatusOK)
+        case r.Method == http.MethodPut && len(paths) == 4 && paths[3] == "metadata":
+            if instance.Metadata == nil {
+                instance.Metadata = make(map[string]string)
+            }
+            for key, values := range r.URL.Query() {
+                instance.Metadata[key] = values[0]
+            }
+            w.WriteHeader(http.StatusOK)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    default:
+        w.WriteHeader(http.StatusNotFound)
+    }
+}
+
+// replicate 处理单个批量复制操作
+func (server *testEurekaServer) replicate(replication *meta.ReplicationInstance) *meta.ReplicationInstanceResponse {
+    appName := strings.ToUpper(replication.AppName)
+    if replication.Action == meta.ReplicationRegister {
+        if replication.InstanceInfo == nil {
+            return &meta.ReplicationInstanceResponse{StatusCode: http.StatusBadRequest}
+        }
+        if _, ok := server.apps[appName]; !ok {
+            server.apps[appName] = make(map[string]*meta.InstanceInfo)
+        }
+        server.apps[appName][replication.Id] = replication.InstanceInfo
+        return &meta.ReplicationInstanceResponse{StatusCode: http.StatusNoContent}
+    }
+    instance := server.apps[appName][replication.Id]
+    if instance == nil {
+        return &meta.ReplicationInstanceResponse{StatusCode: http.StatusNotFound}
+    }
+    switch replication.Action {
+    case meta.ReplicationHeartbeat:
+        return &meta.ReplicationInstanceResponse{StatusCode: http.StatusOK, ResponseEntity: instance}
+    case meta.ReplicationCancel:
+        delete(server.apps[appName], replication.Id)
+    case meta.ReplicationStatusUpdate:
+        instance.Status = replication.Status
+        server.overrides[replication.Id] = replication.Status
+    case meta.ReplicationDeleteStatusOverride:
+        delete(server.overrides, replication.Id)
+        instance.Status = meta.StatusUnknown
+    default:
+        return &meta.ReplicationInstanceResponse{StatusCode: http.StatusBadRequest}
+    }
+    return &meta.ReplicationInstanceResponse{StatusCode: http.StatusOK}
+}
+
+// writeApps 输出满足条件的服务列表
+func (server *testEurekaServer) writeApps(w http.ResponseWriter, predicate func(instance *meta.InstanceInfo) bool) {
+    applications := &meta.Applications{VersionsDelta: 1, Apps: make([]*meta.AppInfo, 0)}
+    for appName, instances := range server.apps {
+        list := make([]*meta.InstanceInfo, 0)
+        for _, instance := range instances {
+            if predicate(instance) {
+                list = append(list, instance)
+            }
+        }
+        if len(list) > 0 {
+            applications.Apps = append(applications.Apps, &meta.AppInfo{Name: appName, Instances: list})
+        }
+    }
+    applications.AppsHashcode = applications.ComputeHashcode()
+    data, _ := applications.ToJson()
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    _, _ = w.Write(data)
+}
+
+// writeJson 输出json响应
+func (server *testEurekaServer) writeJson(w http.ResponseWriter, body interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+    "context"
+    "os"
+    "os/signal"
+    "sync"
+    "syscall"
+    "time"
+)
+
+// DefaultSignalStopTimeout 默认收到退出信号后优雅关闭的最长时间
+var DefaultSignalStopTimeout = 30 * time.Second
+
+// SignalOptions 退出信号处理配置
+type SignalOptions struct {
+    // 监听的信号, 默认: SIGTERM, SIGINT
+    Signals []os.Signal
+    // 收到信号后优雅关闭的最长时间, 默认: DefaultSignalStopTimeout
+    Timeout time.Duration
+    // 优雅关闭配置, 可选
+    GracefulStopOptions *GracefulStopOptions
+    // 关闭完成后回调(交还控制权给应用), 可选
+    OnStopped func(sig os.Signal, response *CommonResponse)
+    // 关闭完成后是否向当前进程重新发送该信号: 仅停止本处理器的信号监听(不调用 signal.Reset),
+    // 若应用未通过 signal.Notify 监听该信号则执行默认处理(如退出进程), 否则该信号交由应用自行处理
+    Reraise bool
+}
+
+// SignalResult 退出信号处理结果
+type SignalResult struct {
+    Signal   os.Signal
+    Response *CommonResponse
+}
+
+// SignalHandler 退出信号处理器
+type SignalHandler struct {
+    signals  chan os.Signal
+    cancel   chan struct{}
+    done     chan *SignalResult
+    stopOnce sync.Once
+}
+
+// Done 信号处理完成后返回处理结果(处理器被取消时关闭通道)
+func (handler *SignalHandler) Done() <-chan *SignalResult {
+    return handler.done
+}
+
+// Stop 取消信号处理(未收到信号时有效)
+func (handler *SignalHandler) Stop() {
+    handler.stopOnce.Do(func() {
+        signal.Stop(handler.signals)
+        close(handler.cancel)
+    })
+}
+
+// HandleSignals 监听退出信号, 收到信号后优雅关闭eureka客户端(取消注册), 再交还控制权给应用或重新发送该信号;
+// 重新发送前仅停止本处理器的监听, 不影响应用自行注册的信号处理
+func (client *EurekaClient) HandleSignals(options *SignalOptions) *SignalHandler {
+    if options == nil {
+        options = &SignalOptions{}
+    }
+    signals := options.Signals
+    if len(signals) == 0 {
+        signals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+    }
+    timeout := options.Timeout
+    if timeout <= 0 {
+        timeout = DefaultSignalStopTimeout
+    }
+    handler := &SignalHandler{
+        signals: make(chan os.Signal, 1),
+        cancel:  make(chan struct{}),
+        done:    make(chan *SignalResult, 1),
+    }
+    signal.Notify(handler.signals, signals...)
+    go func() {
+        var sig os.Signal
+        select {
+        case <-handler.cancel:
+            close(handler.done)
+            return
+        case sig = <-handler.signals:
+        }
+        handler.stopOnce.Do(func() {
+            signal.Stop(handler.signals)
+        })
+        client.GetLogger().Infof("EurekaClient.HandleSignals, received signal: %v, try to stop eureka client", sig)
+        ctx, cancel := context.WithTimeout(context.Background(), timeout)
+        response := client.GracefulStop(ctx, options.GracefulStopOptions)
+        cancel()
+        if response.Error != nil {
+            client.GetLogger().Errorf("EurekaClient.HandleSignals, failed to stop eureka client, error: %v", response.Error)
+        }
+        if options.OnStopped != nil {
+            options.OnStopped(sig, response)
+        }
+        handler.done <- &SignalResult{Signal: sig, Response: response}
+        close(handler.done)
+        if options.Reraise {
+            // 已通过 signal.Stop 停止本处理器监听, 无其他监听时恢复该信号默认处理
+            if process, err := os.FindProcess(os.Getpid()); err == nil {
+                if err = process.Signal(sig); err != nil {
+                    client.GetLogger().Errorf("EurekaClient.HandleSignals, failed to reraise signal: %v, error: %v", sig, err)
+                }
+            }
+        }
+    }()
+    return handler
+}
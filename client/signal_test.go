@@ -0,0 +1,108 @@
+//go:build !windows
+
+package client
+
+import (
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "os"
+    "os/exec"
+    "syscall"
+    "testing"
+    "time"
+)
+
+func TestEurekaClient_HandleSignals(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestEurekaServer()
+    defer server.Close()
+
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{
+            AppName:       "signal-test",
+            InstanceId:    "127.0.0.1:28085",
+            NonSecurePort: 28085,
+        },
+        ClientConfig: &meta.ClientConfig{
+            ServiceUrlOfDefaultZone: server.URL,
+        },
+    })
+    ast.Nilf(err, "%v", err)
+    response := client.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    ast.NotNil(server.Instance("signal-test", "127.0.0.1:28085"))
+
+    stopped := make(chan os.Signal, 1)
+    handler := client.HandleSignals(&SignalOptions{
+        Signals:             []os.Signal{syscall.SIGUSR1},
+        Timeout:             5 * time.Second,
+        GracefulStopOptions: &GracefulStopOptions{DrainPeriod: -1},
+        OnStopped: func(sig os.Signal, response *CommonResponse) {
+            stopped <- sig
+        },
+    })
+    ast.Nil(syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+    select {
+    case result := <-handler.Done():
+        ast.Equal(syscall.SIGUSR1, result.Signal)
+        ast.Nilf(result.Response.Error, "%v", result.Response.Error)
+    case <-time.After(5 * time.Second):
+        ast.Fail("timed out waiting for signal handling")
+    }
+    ast.Equal(syscall.SIGUSR1, <-stopped)
+    ast.Nil(server.Instance("signal-test", "127.0.0.1:28085"))
+    ast.NotNil(client.ChangeStatus(meta.StatusUp).Error)
+}
+
+// TestEurekaClient_HandleSignalsReraise 关闭完成后重新发送信号, 子进程按信号默认处理退出
+func TestEurekaClient_HandleSignalsReraise(t *testing.T) {
+    ast := assert.New(t)
+    if os.Getenv("EUREKA_SIGNAL_RERAISE_CHILD") == "1" {
+        server := newTestEurekaServer()
+        defer server.Close()
+        client, err := NewEurekaClient(&meta.EurekaConfig{
+            InstanceConfig: &meta.InstanceConfig{AppName: "signal-test"},
+            ClientConfig:   &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL, DiscoveryEnabled: &meta.False},
+        })
+        ast.Nilf(err, "%v", err)
+        response := client.Start()
+        ast.Nilf(response.Error, "%v", response.Error)
+        client.HandleSignals(&SignalOptions{
+            Signals:             []os.Signal{syscall.SIGTERM},
+            Timeout:             5 * time.Second,
+            GracefulStopOptions: &GracefulStopOptions{DrainPeriod: -1},
+            Reraise:             true,
+        })
+        ast.Nil(syscall.Kill(os.Getpid(), syscall.SIGTERM))
+        // 重新发送信号后进程应退出, 未退出时正常返回
+        time.Sleep(10 * time.Second)
+        return
+    }
+    cmd := exec.Command(os.Args[0], "-test.run=^TestEurekaClient_HandleSignalsReraise$")
+    cmd.Env = append(os.Environ(), "EUREKA_SIGNAL_RERAISE_CHILD=1")
+    err := cmd.Run()
+    exitErr, ok := err.(*exec.ExitError)
+    if !ast.Truef(ok, "child process should be terminated by signal, error: %v", err) {
+        return
+    }
+    status := exitErr.Sys().(syscall.WaitStatus)
+    ast.True(status.Signaled())
+    ast.Equal(syscall.SIGTERM, status.Signal())
+}
+
+func TestSignalHandler_Stop(t *testing.T) {
+    ast := assert.New(t)
+    client, err := NewEurekaClient(&meta.EurekaConfig{})
+    ast.Nilf(err, "%v", err)
+    handler := client.HandleSignals(&SignalOptions{Signals: []os.Signal{syscall.SIGUSR2}})
+    handler.Stop()
+    handler.Stop()
+    select {
+    case result, ok := <-handler.Done():
+        ast.False(ok)
+        ast.Nil(result)
+    case <-time.After(5 * time.Second):
+        ast.Fail("timed out waiting for signal handler to stop")
+    }
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+// ClientState eureka客户端生命周期状态
+type ClientState string
+
+const (
+    // StateCreated 已创建, 未启动
+    StateCreated ClientState = "CREATED"
+    // StateStarting 启动中
+    StateStarting ClientState = "STARTING"
+    // StateRegistered 运行中, 服务实例已注册且心跳正常
+    StateRegistered ClientState = "REGISTERED"
+    // StateRegistrationFailing 运行中, 服务实例心跳失败
+    StateRegistrationFailing ClientState = "REGISTRATION_FAILING"
+    // StateStopping 关闭中
+    StateStopping ClientState = "STOPPING"
+    // StateStopped 已关闭(可再次启动)
+    StateStopped ClientState = "STOPPED"
+)
+
+// clientStateTransitions 合法的状态变更
+var clientStateTransitions = map[ClientState][]ClientState{
+    StateCreated:             {StateStarting},
+    StateStarting:            {StateRegistered, StateStopped},
+    StateRegistered:          {StateRegistrationFailing, StateStopping, StateStopped},
+    StateRegistrationFailing: {StateRegistered, StateStopping, StateStopped},
+    StateStopping:            {StateRegistered, StateRegistrationFailing, StateStopped},
+    StateStopped:             {StateStarting},
+}
+
+// IsRunning 是否为运行中状态
+func (state ClientState) IsRunning() bool {
+    return state == StateRegistered || state == StateRegistrationFailing
+}
+
+// CanTransitionTo 是否可变更为目标状态
+func (state ClientState) CanTransitionTo(to ClientState) bool {
+    for _, s := range clientStateTransitions[state] {
+        if s == to {
+            return true
+        }
+    }
+    return false
+}
+
+// StateListener 客户端状态变更监听
+type StateListener func(client *EurekaClient, from, to ClientState)
+
+// State 获取客户端当前状态
+func (client *EurekaClient) State() ClientState {
+    client.stateMutex.Lock()
+    defer client.stateMutex.Unlock()
+    return client.currentState()
+}
+
+// AddStateListener 添加客户端状态变更监听, 返回移除监听函数
+func (client *EurekaClient) AddStateListener(listener StateListener) (remove func()) {
+    return client.stateListeners.add(listener)
+}
+
+// currentState 获取客户端当前状态(需持有锁)
+func (client *EurekaClient) currentState() ClientState {
+    if client.state == "" {
+        return StateCreated
+    }
+    return client.state
+}
+
+// transition 变更客户端状态, from 为空时不校验当前状态
+func (client *EurekaClient) transition(to ClientState, from ...ClientState) (ClientState, error) {
+    client.stateMutex.Lock()
+    current := client.currentState()
+    if len(from) > 0 {
+        matched := false
+        for _, s := range from {
+            matched = matched || s == current
+        }
+        if !matched {
+            client.stateMutex.Unlock()
+            return current, errors.New(fmt.Sprintf("eureka client state is %s, expect: %v", current, from))
+        }
+    }
+    if current == to {
+        client.stateMutex.Unlock()
+        return current, nil
+    }
+    if !current.CanTransitionTo(to) {
+        client.stateMutex.Unlock()
+        return current, errors.New(fmt.Sprintf("invalid eureka client state transition: %s -> %s", current, to))
+    }
+    client.state = to
+    client.stateMutex.Unlock()
+    client.GetLogger().Tracef("EurekaClient.transition, %s -> %s", current, to)
+    client.stateListeners.notify(client.GetLogger(), "EurekaClient.transition", func(listener StateListener) {
+        listener(client, current, to)
+    })
+    return current, nil
+}
+
+// watchContext 监听客户端context, 被外部取消时变更状态为 StateStopped
+func (client *EurekaClient) watchContext(ctx context.Context) {
+    <-ctx.Done()
+    client.stateMutex.Lock()
+    current := client.ctx == ctx
+    client.stateMutex.Unlock()
+    if current {
+        _, _ = client.transition(StateStopped, StateRegistered, StateRegistrationFailing)
+    }
+}
+
+// onHeartbeat 根据心跳结果变更注册状态
+func (client *EurekaClient) onHeartbeat(response *CommonResponse) {
+    if response == nil || (response.Response == nil && response.Error == nil) {
+        return
+    }
+    if response.Error != nil {
+        _, _ = client.transition(StateRegistrationFailing, StateRegistered)
+        return
+    }
+    _, _ = client.transition(StateRegistered, StateRegistrationFailing)
+}
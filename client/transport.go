@@ -0,0 +1,198 @@
+package client
+
+import (
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "math/rand"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+)
+
+// LoadBalancerScheme 基于vip进行负载均衡调用的URL协议, 如: lb://vip/path
+const LoadBalancerScheme = "lb"
+
+// DefaultRoundTripperMaxAttempts 默认单次请求最多尝试的服务实例数量
+const DefaultRoundTripperMaxAttempts = 3
+
+// DiscoveryRoundTripper 基于服务发现的 http.RoundTripper
+// 支持 http://APP-NAME/path、https://APP-NAME/path 及 lb://vip/path 格式的请求地址
+type DiscoveryRoundTripper struct {
+    // eureka客户端
+    Client *EurekaClient
+    // 实际发送请求的 http.RoundTripper, 默认: http.DefaultTransport
+    Base http.RoundTripper
+    // 单次请求最多尝试的服务实例数量, 默认: DefaultRoundTripperMaxAttempts
+    MaxAttempts int
+    // http/https请求的主机名未找到对应服务时, 是否直接发送原始请求
+    PassThrough bool
+}
+
+// NewDiscoveryRoundTripper 根据 *EurekaClient 创建 *DiscoveryRoundTripper
+func NewDiscoveryRoundTripper(client *EurekaClient, base http.RoundTripper) *DiscoveryRoundTripper {
+    return &DiscoveryRoundTripper{
+        Client:      client,
+        Base:        base,
+        MaxAttempts: DefaultRoundTripperMaxAttempts,
+    }
+}
+
+// RoundTrip 解析服务实例并发送请求, 连接失败或幂等请求失败时尝试其他服务实例
+func (rt *DiscoveryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+    scheme := strings.ToLower(req.URL.Scheme)
+    host := req.URL.Hostname()
+    var instances []*meta.InstanceInfo
+    var err error
+    switch scheme {
+    case LoadBalancerScheme:
+        instances, err = rt.Client.AccessInstancesByVip(host)
+    case "http", "https":
+        // 主机名未注册为服务时直接发送原始请求(不经 AccessApp, 避免记录错误日志)
+        if rt.PassThrough && !rt.registered(host) {
+            return rt.base().RoundTrip(req)
+        }
+        var app *meta.AppInfo
+        if app, err = rt.Client.AccessApp(host); err == nil {
+            instances = app.Instances
+        }
+        if err != nil && rt.PassThrough {
+            return rt.base().RoundTrip(req)
+        }
+    default:
+        return rt.base().RoundTrip(req)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to resolve service instances, host: %s, error: %w", host, err)
+    }
+    instances = append(make([]*meta.InstanceInfo, 0, len(instances)), instances...)
+    rand.Shuffle(len(instances), func(i, j int) {
+        instances[i], instances[j] = instances[j], instances[i]
+    })
+    attempts := rt.MaxAttempts
+    if attempts <= 0 {
+        attempts = DefaultRoundTripperMaxAttempts
+    }
+    if attempts > len(instances) {
+        attempts = len(instances)
+    }
+    var lastErr error
+    for idx := 0; idx < attempts; idx++ {
+        var attemptReq *http.Request
+        if attemptReq, err = rt.rewrite(req, instances[idx], idx > 0); err != nil {
+            lastErr = err
+            continue
+        }
+        response, err := rt.base().RoundTrip(attemptReq)
+        rt.report(instances[idx], response, err)
+        if err == nil {
+            return response, nil
+        }
+        lastErr = err
+        rt.Client.GetLogger().Tracef("DiscoveryRoundTripper.RoundTrip, request failed >>> idx: %d, url: %s, error: %v", idx, attemptReq.URL, err)
+        if !rt.retryable(req, err) {
+            break
+        }
+    }
+    if lastErr == nil {
+        lastErr = newNoAvailableError("no available service instance found")
+    }
+    return nil, lastErr
+}
+
+// registered 主机名是否为服务发现缓存中已注册的服务
+func (rt *DiscoveryRoundTripper) registered(host string) bool {
+    for _, apps := range rt.Client.DiscoveryClient().Apps {
+        if FilterApp(apps, host) != nil {
+            return true
+        }
+    }
+    return false
+}
+
+// base 获取实际发送请求的 http.RoundTripper
+func (rt *DiscoveryRoundTripper) base() http.RoundTripper {
+    if rt.Base == nil {
+        return http.DefaultTransport
+    }
+    return rt.Base
+}
+
+// report 向异常服务实例检测上报调用结果(连接失败或5xx响应视为失败)
+func (rt *DiscoveryRoundTripper) report(instance *meta.InstanceInfo, response *http.Response, err error) {
+    detector := rt.Client.DiscoveryClient().OutlierDetector
+    if detector == nil {
+        return
+    }
+    if err != nil || response.StatusCode >= 500 {
+        detector.ReportFailure(instance)
+        return
+    }
+    detector.ReportSuccess(instance)
+}
+
+// rewrite 根据服务实例重写请求地址
+func (rt *DiscoveryRoundTripper) rewrite(req *http.Request, instance *meta.InstanceInfo, retry bool) (*http.Request, error) {
+    serviceUrl, err := instanceServiceUrl(instance, req.URL.Scheme)
+    if err != nil {
+        return nil, err
+    }
+    target, err := url.Parse(serviceUrl)
+    if err != nil {
+        return nil, err
+    }
+    newReq := req.Clone(req.Context())
+    newReq.URL.Scheme = target.Scheme
+    newReq.URL.Host = target.Host
+    newReq.Host = ""
+    if retry && req.Body != nil && req.Body != http.NoBody {
+        if req.GetBody == nil {
+            return nil, errors.New("the request body can not be replayed")
+        }
+        if newReq.Body, err = req.GetBody(); err != nil {
+            return nil, err
+        }
+    }
+    return newReq, nil
+}
+
+// retryable 请求失败后是否可尝试其他服务实例
+func (rt *DiscoveryRoundTripper) retryable(req *http.Request, err error) bool {
+    if req.Context().Err() != nil {
+        return false
+    }
+    if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+        return false
+    }
+    return isConnectionError(err) || isIdempotentMethod(req.Method)
+}
+
+// instanceServiceUrl 根据服务实例已启用端口获取调用地址(优先使用请求协议对应的端口)
+func instanceServiceUrl(instance *meta.InstanceInfo, scheme string) (string, error) {
+    if strings.EqualFold(scheme, "http") {
+        if serviceUrl, err := instance.HttpServiceUrl(); err == nil {
+            return serviceUrl, nil
+        }
+        return instance.HttpsServiceUrl()
+    }
+    if serviceUrl, err := instance.HttpsServiceUrl(); err == nil {
+        return serviceUrl, nil
+    }
+    return instance.HttpServiceUrl()
+}
+
+// isConnectionError 是否为建立连接失败(请求未发送)
+func isConnectionError(err error) bool {
+    var opErr *net.OpError
+    return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isIdempotentMethod 是否为幂等请求方法
+func isIdempotentMethod(method string) bool {
+    switch method {
+    case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+    "context"
+    "github.com/jiashunx/eureka-client-go/log"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+// newTestEurekaClient 构造已启动状态(不与eureka server通讯)且使用给定服务发现缓存的eureka客户端
+func newTestEurekaClient(ast *assert.Assertions, apps map[string][]*meta.AppInfo) *EurekaClient {
+    client, err := NewEurekaClient(&meta.EurekaConfig{
+        InstanceConfig: &meta.InstanceConfig{AppName: "transport-test"},
+        ClientConfig:   &meta.ClientConfig{Zone: "zone1", RegistryEnabled: &meta.False},
+    })
+    ast.Nilf(err, "%v", err)
+    client.discoveryClient.Apps = apps
+    client.ctx, client.ctxCancel = context.WithCancel(context.Background())
+    client.state = StateRegistered
+    return client
+}
+
+// newTestServerInstance 构造指向指定地址的服务实例
+func newTestServerInstance(ast *assert.Assertions, appName, instanceId, rawUrl string) *meta.InstanceInfo {
+    URL, err := url.Parse(rawUrl)
+    ast.Nilf(err, "%v", err)
+    port, err := strconv.Atoi(URL.Port())
+    ast.Nilf(err, "%v", err)
+    instance := newTestQueryInstance(appName, instanceId, URL.Hostname(), "zone1", meta.StatusUp, map[string]string{}, time.Now())
+    instance.Port.Port = port
+    instance.VipAddress = strings.ToLower(appName) + "-vip"
+    return instance
+}
+
+// newTestClosedUrl 获取一个无服务监听的地址
+func newTestClosedUrl(ast *assert.Assertions) string {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    ast.Nilf(err, "%v", err)
+    addr := listener.Addr().String()
+    ast.Nil(listener.Close())
+    return "http://" + addr
+}
+
+func TestDiscoveryRoundTripper(t *testing.T) {
+    ast := assert.New(t)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        _, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+    }))
+    defer server.Close()
+    closedUrl := newTestClosedUrl(ast)
+    client := newTestEurekaClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {
+            {Name: "ORDER", Instances: []*meta.InstanceInfo{
+                newTestServerInstance(ast, "ORDER", "order-1", server.URL),
+                newTestServerInstance(ast, "ORDER", "order-2", closedUrl),
+            }},
+            {Name: "DEAD", Instances: []*meta.InstanceInfo{
+                newTestServerInstance(ast, "DEAD", "dead-1", closedUrl),
+            }},
+        },
+    })
+    defer client.ctxCancel()
+    httpClient := &http.Client{Transport: NewDiscoveryRoundTripper(client, nil)}
+
+    // 连接失败时自动尝试其他服务实例
+    for i := 0; i < 5; i++ {
+        response, err := httpClient.Get("http://ORDER/hello")
+        ast.Nilf(err, "%v", err)
+        body, _ := io.ReadAll(response.Body)
+        _ = response.Body.Close()
+        ast.Equal("GET /hello ", string(body))
+    }
+
+    // 非幂等请求在连接失败时同样可尝试其他服务实例
+    for i := 0; i < 5; i++ {
+        response, err := httpClient.Post("lb://order-vip/echo", "text/plain", strings.NewReader("payload"))
+        ast.Nilf(err, "%v", err)
+        body, _ := io.ReadAll(response.Body)
+        _ = response.Body.Close()
+        ast.Equal("POST /echo payload", string(body))
+    }
+
+    _, err := httpClient.Get("http://DEAD/hello")
+    ast.NotNil(err)
+
+    _, err = httpClient.Get("http://MISSING/hello")
+    ast.NotNil(err)
+}
+
+func TestDiscoveryRoundTripper_PassThrough(t *testing.T) {
+    ast := assert.New(t)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, _ = w.Write([]byte("direct"))
+    }))
+    defer server.Close()
+    client := newTestEurekaClient(ast, map[string][]*meta.AppInfo{
+        "zone1": {{Name: "ORDER", Instances: []*meta.InstanceInfo{newTestQueryInstance("ORDER", "order-1", "127.0.0.1", "zone1", meta.StatusDown, map[string]string{}, time.Now())}}},
+    })
+    defer client.ctxCancel()
+    logger := &testErrorLogger{Logger: log.DefaultLoggerImpl}
+    client.logger = logger
+    rt := NewDiscoveryRoundTripper(client, nil)
+    rt.PassThrough = true
+    response, err := (&http.Client{Transport: rt}).Get(server.URL)
+    ast.Nilf(err, "%v", err)
+    body, _ := io.ReadAll(response.Body)
+    _ = response.Body.Close()
+    ast.Equal("direct", string(body))
+    // 未注册的主机名不记录错误日志
+    ast.Equal(int32(0), atomic.LoadInt32(&logger.errors))
+
+    // 已注册但无可用服务实例时记录错误日志
+    rt.PassThrough = false
+    _, err = (&http.Client{Transport: rt}).Get("http://order/")
+    ast.NotNil(err)
+    ast.Equal(int32(1), atomic.LoadInt32(&logger.errors))
+}
+
+// testErrorLogger 统计错误日志数量
+type testErrorLogger struct {
+    log.Logger
+    errors int32
+}
+
+// Errorf 统计错误日志数量
+func (logger *testErrorLogger) Errorf(format string, a ...any) {
+    atomic.AddInt32(&logger.errors, 1)
+    logger.Logger.Errorf(format, a...)
+}
+
+func TestInstanceServiceUrl(t *testing.T) {
+    ast := assert.New(t)
+    instance := &meta.InstanceInfo{
+        HostName:   "127.0.0.1",
+        Port:       &meta.PortWrapper{Enabled: meta.StrTrue, Port: 80},
+        SecurePort: &meta.PortWrapper{Enabled: meta.StrTrue, Port: 443},
+    }
+    serviceUrl, _ := instanceServiceUrl(instance, "http")
+    ast.Equal("http://127.0.0.1:80", serviceUrl)
+    serviceUrl, _ = instanceServiceUrl(instance, LoadBalancerScheme)
+    ast.Equal("https://127.0.0.1:443", serviceUrl)
+    instance.SecurePort.Enabled = meta.StrFalse
+    serviceUrl, _ = instanceServiceUrl(instance, "https")
+    ast.Equal("http://127.0.0.1:80", serviceUrl)
+    instance.Port.Enabled = meta.StrFalse
+    _, err := instanceServiceUrl(instance, "http")
+    ast.NotNil(err)
+}
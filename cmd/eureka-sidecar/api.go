@@ -0,0 +1,89 @@
+package main
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+)
+
+// Handler 本地http接口, 供本地进程查询服务发现结果:
+//
+//	GET /apps/{appName}           查询服务可用实例列表
+//	GET /apps/{appName}/instance  选择一个服务可用实例(按路由规则随机选择)
+//	GET /vips/{vip}               查询vip可用实例列表
+//	GET /svips/{svip}             查询svip可用实例列表
+//	GET /status                   查询sidecar及健康检查状态
+//	GET /health/readiness         就绪检查
+//	GET /health/liveness          存活检查
+func (sidecar *Sidecar) Handler() http.Handler {
+    readiness := sidecar.client.ReadinessHandler(nil)
+    liveness := sidecar.client.LivenessHandler(nil)
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet {
+            w.Header().Set("Allow", http.MethodGet)
+            writeError(w, http.StatusMethodNotAllowed, "method not allowed: "+r.Method)
+            return
+        }
+        paths := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+        switch {
+        case len(paths) == 2 && paths[0] == "apps":
+            app, err := sidecar.client.AccessApp(paths[1])
+            if err != nil {
+                writeError(w, http.StatusNotFound, err.Error())
+                return
+            }
+            writeJson(w, http.StatusOK, app.Instances)
+        case len(paths) == 3 && paths[0] == "apps" && paths[2] == "instance":
+            instance, err := sidecar.client.AccessRoutedInstance(paths[1])
+            if err != nil {
+                writeError(w, http.StatusNotFound, err.Error())
+                return
+            }
+            writeJson(w, http.StatusOK, instance)
+        case len(paths) == 2 && paths[0] == "vips":
+            instances, err := sidecar.client.AccessInstancesByVip(paths[1])
+            if err != nil {
+                writeError(w, http.StatusNotFound, err.Error())
+                return
+            }
+            writeJson(w, http.StatusOK, instances)
+        case len(paths) == 2 && paths[0] == "svips":
+            instances, err := sidecar.client.AccessInstancesBySvip(paths[1])
+            if err != nil {
+                writeError(w, http.StatusNotFound, err.Error())
+                return
+            }
+            writeJson(w, http.StatusOK, instances)
+        case len(paths) == 1 && paths[0] == "status":
+            writeJson(w, http.StatusOK, map[string]interface{}{
+                "app":         sidecar.config.Eureka.AppName,
+                "instanceId":  sidecar.config.Eureka.InstanceId,
+                "state":       sidecar.client.State(),
+                "healthCheck": sidecar.Result(),
+            })
+        case len(paths) == 2 && paths[0] == "health" && paths[1] == "readiness":
+            readiness.ServeHTTP(w, r)
+        case len(paths) == 2 && paths[0] == "health" && paths[1] == "liveness":
+            liveness.ServeHTTP(w, r)
+        default:
+            writeError(w, http.StatusNotFound, "not found: "+r.URL.Path)
+        }
+    })
+}
+
+// writeError 输出JSON格式错误信息
+func writeError(w http.ResponseWriter, code int, message string) {
+    writeJson(w, code, map[string]string{"error": message})
+}
+
+// writeJson 输出JSON格式响应
+func writeJson(w http.ResponseWriter, code int, body interface{}) {
+    data, err := json.Marshal(body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(code)
+    _, _ = w.Write(data)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "gopkg.in/yaml.v3"
+    "os"
+    "strings"
+    "time"
+)
+
+const (
+    // CheckTypeHttp http健康检查(响应码为2xx时健康)
+    CheckTypeHttp = "http"
+    // CheckTypeTcp tcp健康检查(可建立连接时健康)
+    CheckTypeTcp = "tcp"
+    // CheckTypeNone 不进行健康检查(注册后状态即为UP)
+    CheckTypeNone = "none"
+)
+
+var (
+    // DefaultListen 默认本地http接口监听地址
+    DefaultListen = "127.0.0.1:8765"
+    // DefaultCheckIntervalSeconds 默认健康检查间隔秒数
+    DefaultCheckIntervalSeconds = 10
+    // DefaultCheckTimeoutSeconds 默认健康检查超时秒数
+    DefaultCheckTimeoutSeconds = 3
+    // DefaultFailureThreshold 默认健康检查连续失败多少次后变更状态为DOWN
+    DefaultFailureThreshold = 3
+    // DefaultSuccessThreshold 默认健康检查连续成功多少次后变更状态为UP
+    DefaultSuccessThreshold = 1
+)
+
+// HealthCheckConfig 本地进程健康检查配置
+type HealthCheckConfig struct {
+    // 检查类型: http, tcp, none, 默认: 指定 Url 时为http, 指定 Address 时为tcp, 否则为none
+    Type string `json:"type"`
+    // http健康检查地址
+    Url string `json:"url"`
+    // tcp健康检查地址(host:port)
+    Address string `json:"address"`
+    // 检查间隔秒数, 默认: DefaultCheckIntervalSeconds
+    IntervalSeconds int `json:"interval-seconds"`
+    // 检查超时秒数, 默认: DefaultCheckTimeoutSeconds
+    TimeoutSeconds int `json:"timeout-seconds"`
+    // 连续失败多少次后变更状态为DOWN, 默认: DefaultFailureThreshold
+    FailureThreshold int `json:"failure-threshold"`
+    // 连续成功多少次后变更状态为UP, 默认: DefaultSuccessThreshold
+    SuccessThreshold int `json:"success-threshold"`
+}
+
+// SidecarConfig sidecar配置
+type SidecarConfig struct {
+    // 本地http接口监听地址, 默认: DefaultListen
+    Listen string `json:"listen"`
+    // 服务实例及eureka客户端配置(属性名称与 meta.EurekaConfig 一致)
+    Eureka *meta.EurekaConfig `json:"eureka"`
+    // 本地进程健康检查配置
+    HealthCheck *HealthCheckConfig `json:"health-check"`
+}
+
+// Interval 检查间隔
+func (config *HealthCheckConfig) Interval() time.Duration {
+    return time.Duration(config.IntervalSeconds) * time.Second
+}
+
+// Timeout 检查超时时间
+func (config *HealthCheckConfig) Timeout() time.Duration {
+    return time.Duration(config.TimeoutSeconds) * time.Second
+}
+
+// check 检查配置并设置默认值
+func (config *SidecarConfig) check() error {
+    config.Listen = strings.TrimSpace(config.Listen)
+    if config.Listen == "" {
+        config.Listen = DefaultListen
+    }
+    if config.Eureka == nil {
+        config.Eureka = &meta.EurekaConfig{}
+    }
+    if config.HealthCheck == nil {
+        config.HealthCheck = &HealthCheckConfig{}
+    }
+    hc := config.HealthCheck
+    hc.Type = strings.ToLower(strings.TrimSpace(hc.Type))
+    if hc.Type == "" {
+        hc.Type = CheckTypeNone
+        if hc.Address != "" {
+            hc.Type = CheckTypeTcp
+        }
+        if hc.Url != "" {
+            hc.Type = CheckTypeHttp
+        }
+    }
+    switch hc.Type {
+    case CheckTypeHttp:
+        if hc.Url == "" {
+            return errors.New("health-check.url is required for http health check")
+        }
+    case CheckTypeTcp:
+        if hc.Address == "" {
+            return errors.New("health-check.address is required for tcp health check")
+        }
+    case CheckTypeNone:
+    default:
+        return errors.New("health-check.type is invalid: " + hc.Type)
+    }
+    if hc.IntervalSeconds <= 0 {
+        hc.IntervalSeconds = DefaultCheckIntervalSeconds
+    }
+    if hc.TimeoutSeconds <= 0 {
+        hc.TimeoutSeconds = DefaultCheckTimeoutSeconds
+    }
+    if hc.FailureThreshold <= 0 {
+        hc.FailureThreshold = DefaultFailureThreshold
+    }
+    if hc.SuccessThreshold <= 0 {
+        hc.SuccessThreshold = DefaultSuccessThreshold
+    }
+    if hc.Type != CheckTypeNone {
+        // 健康检查通过后才变更状态为UP
+        config.Eureka.InstanceConfig = withInstanceEnabledOnIt(config.Eureka.InstanceConfig, false)
+    }
+    return config.Eureka.Check()
+}
+
+// withInstanceEnabledOnIt 设置是否注册后立即启用实例
+func withInstanceEnabledOnIt(config *meta.InstanceConfig, enabled bool) *meta.InstanceConfig {
+    if config == nil {
+        config = &meta.InstanceConfig{}
+    }
+    config.InstanceEnabledOnIt = &enabled
+    return config
+}
+
+// LoadSidecarConfig 从JSON/YAML格式配置文件中加载sidecar配置
+func LoadSidecarConfig(path string) (*SidecarConfig, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    return ParseSidecarConfig(data)
+}
+
+// ParseSidecarConfig 从JSON/YAML格式内容中解析sidecar配置
+func ParseSidecarConfig(data []byte) (config *SidecarConfig, err error) {
+    var value interface{}
+    if err = yaml.Unmarshal(data, &value); err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to parse sidecar config: %v", err))
+    }
+    if value == nil {
+        value = map[string]interface{}{}
+    }
+    if data, err = json.Marshal(value); err != nil {
+        return nil, err
+    }
+    config = &SidecarConfig{}
+    if err = json.Unmarshal(data, config); err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to parse sidecar config: %v", err))
+    }
+    return config, config.check()
+}
@@ -0,0 +1,92 @@
+// eureka-sidecar 代理无法集成eureka客户端的本地进程(如其他语言实现的服务)进行服务注册及发现:
+// 读取服务实例配置, 通过http/tcp检查本地进程健康状态, 代为注册及发送心跳, 根据健康状态变更服务实例状态,
+// 并提供本地http接口供本地进程查询服务发现结果
+//
+// 用法:
+//
+//	eureka-sidecar -config sidecar.yaml [-listen 127.0.0.1:8765]
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/client"
+    "io"
+    "net"
+    "net/http"
+    "os"
+    "time"
+)
+
+// EnvConfig 配置文件路径环境变量
+const EnvConfig = "EUREKA_SIDECAR_CONFIG"
+
+// run 启动sidecar直至收到退出信号, 返回进程退出码
+func run(args []string, stderr io.Writer) int {
+    var configFile, listen string
+    fs := flag.NewFlagSet("eureka-sidecar", flag.ContinueOnError)
+    fs.SetOutput(stderr)
+    fs.StringVar(&configFile, "config", os.Getenv(EnvConfig), "sidecar config file (JSON/YAML), env: "+EnvConfig)
+    fs.StringVar(&listen, "listen", "", "local http api listen address (default "+DefaultListen+")")
+    if err := fs.Parse(args); err != nil {
+        if errors.Is(err, flag.ErrHelp) {
+            return 0
+        }
+        return 2
+    }
+    if configFile == "" {
+        _, _ = fmt.Fprintln(stderr, "error: config file is required, use -config or "+EnvConfig)
+        return 2
+    }
+    config, err := LoadSidecarConfig(configFile)
+    if err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        return 1
+    }
+    if listen != "" {
+        config.Listen = listen
+    }
+    eurekaClient, err := client.NewEurekaClient(config.Eureka)
+    if err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        return 1
+    }
+    sidecar := NewSidecar(config, eurekaClient)
+    listener, err := net.Listen("tcp", config.Listen)
+    if err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        return 1
+    }
+    server := &http.Server{Handler: sidecar.Handler()}
+    go func() {
+        if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            eurekaClient.GetLogger().Errorf("eureka-sidecar, local http api stopped, error: %v", err)
+        }
+    }()
+    signals := eurekaClient.HandleSignals(&client.SignalOptions{GracefulStopOptions: &client.GracefulStopOptions{DrainPeriod: -1}})
+    if response := eurekaClient.Start(); response.Error != nil {
+        _, _ = fmt.Fprintf(stderr, "error: failed to start eureka client: %v\n", response.Error)
+        signals.Stop()
+        _ = server.Close()
+        return 1
+    }
+    eurekaClient.GetLogger().Infof("eureka-sidecar, started, app: %s, instanceId: %s, listen: %s", config.Eureka.AppName, config.Eureka.InstanceId, listener.Addr())
+    ctx, cancel := context.WithCancel(context.Background())
+    go sidecar.Run(ctx)
+    result := <-signals.Done()
+    cancel()
+    shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer shutdownCancel()
+    _ = server.Shutdown(shutdownCtx)
+    if result != nil && result.Response.Error != nil {
+        _, _ = fmt.Fprintf(stderr, "error: failed to stop eureka client: %v\n", result.Response.Error)
+        return 1
+    }
+    return 0
+}
+
+func main() {
+    os.Exit(run(os.Args[1:], os.Stderr))
+}
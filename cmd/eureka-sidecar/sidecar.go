@@ -0,0 +1,154 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/client"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// CheckResult 健康检查结果
+type CheckResult struct {
+    // 当前服务实例状态
+    Status meta.InstanceStatus `json:"status"`
+    // 最近一次检查时间
+    LastCheckTime *time.Time `json:"lastCheckTime,omitempty"`
+    // 最近一次检查错误
+    LastCheckError string `json:"lastCheckError,omitempty"`
+    // 连续成功次数
+    Successes int `json:"successes"`
+    // 连续失败次数
+    Failures int `json:"failures"`
+}
+
+// Sidecar 代理本地进程进行服务注册、心跳及状态变更
+type Sidecar struct {
+    config     *SidecarConfig
+    client     *client.EurekaClient
+    httpClient *http.Client
+    mutex      sync.Mutex
+    result     CheckResult
+}
+
+// NewSidecar 创建sidecar
+func NewSidecar(config *SidecarConfig, eurekaClient *client.EurekaClient) *Sidecar {
+    status := meta.StatusUp
+    if !*config.Eureka.InstanceEnabledOnIt {
+        status = meta.StatusStarting
+    }
+    return &Sidecar{
+        config:     config,
+        client:     eurekaClient,
+        httpClient: &http.Client{Timeout: config.HealthCheck.Timeout()},
+        result:     CheckResult{Status: status},
+    }
+}
+
+// Result 获取健康检查结果
+func (sidecar *Sidecar) Result() CheckResult {
+    sidecar.mutex.Lock()
+    defer sidecar.mutex.Unlock()
+    return sidecar.result
+}
+
+// Run 周期性检查本地进程健康状态, 直至 ctx 取消
+func (sidecar *Sidecar) Run(ctx context.Context) {
+    if sidecar.config.HealthCheck.Type == CheckTypeNone {
+        return
+    }
+    ticker := time.NewTicker(sidecar.config.HealthCheck.Interval())
+    defer ticker.Stop()
+    for {
+        sidecar.CheckOnce(ctx)
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// CheckOnce 检查一次本地进程健康状态, 达到阈值时变更服务实例状态
+func (sidecar *Sidecar) CheckOnce(ctx context.Context) {
+    err := sidecar.check(ctx)
+    hc := sidecar.config.HealthCheck
+    sidecar.mutex.Lock()
+    now := time.Now()
+    result := &sidecar.result
+    result.LastCheckTime = &now
+    result.LastCheckError = ""
+    target := result.Status
+    if err != nil {
+        result.LastCheckError = err.Error()
+        result.Successes = 0
+        result.Failures++
+        if result.Failures >= hc.FailureThreshold {
+            target = meta.StatusDown
+        }
+    } else {
+        result.Failures = 0
+        result.Successes++
+        if result.Successes >= hc.SuccessThreshold {
+            target = meta.StatusUp
+        }
+    }
+    current := result.Status
+    sidecar.mutex.Unlock()
+    if target == current {
+        return
+    }
+    sidecar.client.GetLogger().Infof("Sidecar.CheckOnce, change status: %s -> %s, check error: %v", current, target, err)
+    if response := sidecar.changeStatus(target); response.Error != nil {
+        sidecar.client.GetLogger().Errorf("Sidecar.CheckOnce, failed to change status to %s, error: %v", target, response.Error)
+        return
+    }
+    sidecar.mutex.Lock()
+    sidecar.result.Status = target
+    sidecar.mutex.Unlock()
+}
+
+// changeStatus 变更服务实例状态, 服务实例不存在时(如: 因未发送心跳被剔除)重新注册后重试
+func (sidecar *Sidecar) changeStatus(status meta.InstanceStatus) *client.CommonResponse {
+    response := sidecar.client.ChangeStatus(status)
+    if response.Error == nil || !errors.Is(response.Error, client.ErrInstanceNotFound) {
+        return response
+    }
+    if reRegister := sidecar.client.ReRegister(); reRegister.Error != nil {
+        return response
+    }
+    return sidecar.client.ChangeStatus(status)
+}
+
+// check 执行健康检查
+func (sidecar *Sidecar) check(ctx context.Context) error {
+    hc := sidecar.config.HealthCheck
+    switch hc.Type {
+    case CheckTypeHttp:
+        request, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.Url, nil)
+        if err != nil {
+            return err
+        }
+        response, err := sidecar.httpClient.Do(request)
+        if err != nil {
+            return err
+        }
+        _ = response.Body.Close()
+        if response.StatusCode < 200 || response.StatusCode >= 300 {
+            return errors.New(fmt.Sprintf("health check response code is %d", response.StatusCode))
+        }
+        return nil
+    case CheckTypeTcp:
+        dialer := &net.Dialer{Timeout: hc.Timeout()}
+        conn, err := dialer.DialContext(ctx, "tcp", hc.Address)
+        if err != nil {
+            return err
+        }
+        return conn.Close()
+    }
+    return nil
+}
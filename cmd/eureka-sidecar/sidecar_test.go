@@ -0,0 +1,233 @@
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "github.com/jiashunx/eureka-client-go/client"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "testing"
+)
+
+// testEurekaServer eureka server替身, 仅支持sidecar使用的接口
+type testEurekaServer struct {
+    *httptest.Server
+    mutex     sync.Mutex
+    instances map[string]*meta.InstanceInfo
+    statuses  []string
+}
+
+// newTestEurekaServer 创建eureka server替身
+func newTestEurekaServer() *testEurekaServer {
+    server := &testEurekaServer{instances: make(map[string]*meta.InstanceInfo)}
+    server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        server.mutex.Lock()
+        defer server.mutex.Unlock()
+        paths := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+        switch {
+        case r.Method == http.MethodPost && len(paths) == 2:
+            body := make(map[string]*meta.InstanceInfo)
+            _ = json.NewDecoder(r.Body).Decode(&body)
+            server.instances[body["instance"].InstanceId] = body["instance"]
+            w.WriteHeader(http.StatusNoContent)
+        case r.Method == http.MethodGet && r.URL.Path == "/apps":
+            apps := make(map[string][]*meta.InstanceInfo)
+            for _, instance := range server.instances {
+                apps[instance.AppName] = append(apps[instance.AppName], instance)
+            }
+            list := make([]interface{}, 0)
+            for name, instances := range apps {
+                list = append(list, map[string]interface{}{"name": name, "instance": instances})
+            }
+            w.Header().Set("Content-Type", "application/json")
+            _ = json.NewEncoder(w).Encode(map[string]interface{}{"applications": map[string]interface{}{"application": list}})
+        case r.Method == http.MethodPut && len(paths) == 4 && paths[3] == "status":
+            instance := server.instances[paths[2]]
+            if instance == nil {
+                w.WriteHeader(http.StatusNotFound)
+                return
+            }
+            instance.Status = meta.InstanceStatus(r.URL.Query().Get("value"))
+            server.statuses = append(server.statuses, string(instance.Status))
+            w.WriteHeader(http.StatusOK)
+        case (r.Method == http.MethodPut || r.Method == http.MethodDelete) && len(paths) == 3:
+            if server.instances[paths[2]] == nil {
+                w.WriteHeader(http.StatusNotFound)
+                return
+            }
+            if r.Method == http.MethodDelete {
+                delete(server.instances, paths[2])
+            }
+            w.WriteHeader(http.StatusOK)
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    return server
+}
+
+// Statuses 获取状态变更记录
+func (server *testEurekaServer) Statuses() []string {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return append(make([]string, 0), server.statuses...)
+}
+
+// Evict 剔除服务实例
+func (server *testEurekaServer) Evict(instanceId string) {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    delete(server.instances, instanceId)
+}
+
+// newTestSidecar 创建并启动sidecar
+func newTestSidecar(ast *assert.Assertions, serviceUrl, healthUrl string) *Sidecar {
+    config, err := ParseSidecarConfig([]byte(`
+eureka:
+  app-name: legacy
+  instance-id: legacy-1
+  non-secure-port: 9090
+  service-url-of-default-zone: ` + serviceUrl + `
+health-check:
+  url: ` + healthUrl + `
+  failure-threshold: 2
+`))
+    ast.Nilf(err, "%v", err)
+    eurekaClient, err := client.NewEurekaClient(config.Eureka)
+    ast.Nilf(err, "%v", err)
+    response := eurekaClient.Start()
+    ast.Nilf(response.Error, "%v", response.Error)
+    return NewSidecar(config, eurekaClient)
+}
+
+func TestParseSidecarConfig(t *testing.T) {
+    ast := assert.New(t)
+    config, err := ParseSidecarConfig([]byte(`{"eureka": {"app-name": "legacy"}, "health-check": {"address": "127.0.0.1:9090"}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal(DefaultListen, config.Listen)
+    ast.Equal("legacy", config.Eureka.AppName)
+    ast.Equal(CheckTypeTcp, config.HealthCheck.Type)
+    ast.Equal(DefaultFailureThreshold, config.HealthCheck.FailureThreshold)
+    ast.False(*config.Eureka.InstanceEnabledOnIt)
+
+    config, err = ParseSidecarConfig([]byte("eureka:\n  app-name: legacy\n"))
+    ast.Nilf(err, "%v", err)
+    ast.Equal(CheckTypeNone, config.HealthCheck.Type)
+    ast.True(*config.Eureka.InstanceEnabledOnIt)
+
+    _, err = ParseSidecarConfig([]byte("health-check:\n  type: http\n"))
+    ast.NotNil(err)
+    _, err = ParseSidecarConfig([]byte("health-check:\n  type: grpc\n"))
+    ast.NotNil(err)
+}
+
+func TestSidecar_CheckOnce(t *testing.T) {
+    ast := assert.New(t)
+    eureka := newTestEurekaServer()
+    defer eureka.Close()
+    var healthy atomic.Bool
+    health := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if !healthy.Load() {
+            w.WriteHeader(http.StatusServiceUnavailable)
+        }
+    }))
+    defer health.Close()
+    sidecar := newTestSidecar(ast, eureka.URL, health.URL)
+    defer sidecar.client.Stop()
+    ast.Equal(meta.StatusStarting, sidecar.Result().Status)
+
+    // 健康检查通过后变更状态为UP
+    healthy.Store(true)
+    sidecar.CheckOnce(context.Background())
+    ast.Equal(meta.StatusUp, sidecar.Result().Status)
+    ast.Equal([]string{"UP"}, eureka.Statuses())
+
+    // 连续失败达到阈值后变更状态为DOWN
+    healthy.Store(false)
+    sidecar.CheckOnce(context.Background())
+    ast.Equal(meta.StatusUp, sidecar.Result().Status)
+    ast.Equal(1, sidecar.Result().Failures)
+    ast.NotEmpty(sidecar.Result().LastCheckError)
+    sidecar.CheckOnce(context.Background())
+    ast.Equal(meta.StatusDown, sidecar.Result().Status)
+    ast.Equal([]string{"UP", "DOWN"}, eureka.Statuses())
+
+    // 服务实例被剔除后重新注册
+    eureka.Evict("legacy-1")
+    healthy.Store(true)
+    sidecar.CheckOnce(context.Background())
+    ast.Equal(meta.StatusUp, sidecar.Result().Status)
+    ast.Equal([]string{"UP", "DOWN", "UP"}, eureka.Statuses())
+}
+
+func TestSidecar_TcpCheck(t *testing.T) {
+    ast := assert.New(t)
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    ast.Nilf(err, "%v", err)
+    sidecar := &Sidecar{config: &SidecarConfig{HealthCheck: &HealthCheckConfig{Type: CheckTypeTcp, Address: listener.Addr().String(), TimeoutSeconds: 1}}}
+    ast.Nil(sidecar.check(context.Background()))
+    ast.Nil(listener.Close())
+    ast.NotNil(sidecar.check(context.Background()))
+}
+
+func TestSidecar_Handler(t *testing.T) {
+    ast := assert.New(t)
+    eureka := newTestEurekaServer()
+    defer eureka.Close()
+    health := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer health.Close()
+    sidecar := newTestSidecar(ast, eureka.URL, health.URL)
+    defer sidecar.client.Stop()
+    sidecar.CheckOnce(context.Background())
+    _, err := sidecar.client.FetchApps()
+    ast.Nilf(err, "%v", err)
+    handler := sidecar.Handler()
+    get := func(target string) (int, []byte) {
+        recorder := httptest.NewRecorder()
+        handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+        return recorder.Code, recorder.Body.Bytes()
+    }
+
+    code, body := get("/apps/legacy")
+    ast.Equal(http.StatusOK, code)
+    instances := make([]*meta.InstanceInfo, 0)
+    ast.Nil(json.Unmarshal(body, &instances))
+    ast.Len(instances, 1)
+    ast.Equal("legacy-1", instances[0].InstanceId)
+
+    code, body = get("/apps/legacy/instance")
+    ast.Equal(http.StatusOK, code)
+    ast.True(bytes.Contains(body, []byte("legacy-1")))
+
+    code, _ = get("/vips/legacy")
+    ast.Equal(http.StatusOK, code)
+    code, _ = get("/apps/missing")
+    ast.Equal(http.StatusNotFound, code)
+
+    code, body = get("/status")
+    ast.Equal(http.StatusOK, code)
+    ast.True(bytes.Contains(body, []byte(`"status":"UP"`)))
+
+    code, _ = get("/health/liveness")
+    ast.Equal(http.StatusOK, code)
+
+    recorder := httptest.NewRecorder()
+    handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/status", nil))
+    ast.Equal(http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestRun_MissingConfig(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv(EnvConfig, "")
+    stderr := &bytes.Buffer{}
+    ast.Equal(2, run([]string{}, stderr))
+    ast.Contains(stderr.String(), "config file is required")
+    ast.Equal(1, run([]string{"-config", "/nonexistent/sidecar.yaml"}, stderr))
+}
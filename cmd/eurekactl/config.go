@@ -0,0 +1,137 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "gopkg.in/yaml.v3"
+    "os"
+    "strconv"
+    "strings"
+)
+
+const (
+    // EnvConfig 配置文件路径环境变量
+    EnvConfig = "EUREKACTL_CONFIG"
+    // EnvServiceUrl eureka server服务地址环境变量(以逗号分隔)
+    EnvServiceUrl = "EUREKA_SERVICE_URL"
+    // EnvUsername BasicAuth用户名环境变量
+    EnvUsername = "EUREKA_USERNAME"
+    // EnvPassword BasicAuth密码环境变量
+    EnvPassword = "EUREKA_PASSWORD"
+    // EnvTimeoutSeconds 请求超时秒数环境变量
+    EnvTimeoutSeconds = "EUREKA_TIMEOUT_SECONDS"
+    // EnvOutput 输出格式环境变量
+    EnvOutput = "EUREKACTL_OUTPUT"
+)
+
+const (
+    // OutputTable 表格输出
+    OutputTable = "table"
+    // OutputJson JSON输出
+    OutputJson = "json"
+)
+
+// DefaultTimeoutSeconds 默认请求超时秒数
+var DefaultTimeoutSeconds = 10
+
+// ctlConfig eurekactl配置(配置文件中属性名称与 meta.EurekaServer 一致)
+type ctlConfig struct {
+    meta.EurekaServer
+    // 输出格式: table, json
+    Output string `json:"output"`
+}
+
+// loadConfigFile 从JSON/YAML格式配置文件中加载配置
+func loadConfigFile(path string, config *ctlConfig) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    data, err = yamlToJson(data)
+    if err != nil {
+        return errors.New(fmt.Sprintf("failed to parse config file %s: %v", path, err))
+    }
+    if err = json.Unmarshal(data, config); err != nil {
+        return errors.New(fmt.Sprintf("failed to parse config file %s: %v", path, err))
+    }
+    return nil
+}
+
+// loadConfigEnv 从环境变量中加载配置
+func loadConfigEnv(config *ctlConfig) error {
+    if value, ok := os.LookupEnv(EnvServiceUrl); ok {
+        config.ServiceUrl = value
+    }
+    if value, ok := os.LookupEnv(EnvUsername); ok {
+        config.Username = value
+    }
+    if value, ok := os.LookupEnv(EnvPassword); ok {
+        config.Password = value
+    }
+    if value, ok := os.LookupEnv(EnvTimeoutSeconds); ok {
+        seconds, err := strconv.Atoi(value)
+        if err != nil {
+            return errors.New(fmt.Sprintf("invalid %s: %s", EnvTimeoutSeconds, value))
+        }
+        config.ReadTimeoutSeconds = seconds
+        config.ConnectTimeoutSeconds = seconds
+    }
+    if value, ok := os.LookupEnv(EnvOutput); ok {
+        config.Output = value
+    }
+    return nil
+}
+
+// check 检查配置并设置默认值
+func (config *ctlConfig) check() error {
+    config.ServiceUrl = strings.TrimSpace(config.ServiceUrl)
+    if config.ServiceUrl == "" {
+        return errors.New(fmt.Sprintf("eureka server service url is required, use -server or %s", EnvServiceUrl))
+    }
+    if config.ReadTimeoutSeconds <= 0 && config.ConnectTimeoutSeconds <= 0 {
+        config.ReadTimeoutSeconds = DefaultTimeoutSeconds
+        config.ConnectTimeoutSeconds = DefaultTimeoutSeconds
+    }
+    config.Output = strings.ToLower(strings.TrimSpace(config.Output))
+    if config.Output == "" {
+        config.Output = OutputTable
+    }
+    if config.Output != OutputTable && config.Output != OutputJson {
+        return errors.New("output format is invalid: " + config.Output)
+    }
+    return nil
+}
+
+// yamlToJson 将YAML(兼容JSON)格式内容转换为JSON格式
+func yamlToJson(data []byte) ([]byte, error) {
+    var value interface{}
+    if err := yaml.Unmarshal(data, &value); err != nil {
+        return nil, err
+    }
+    if value == nil {
+        value = map[string]interface{}{}
+    }
+    return json.Marshal(value)
+}
+
+// parseInstanceFile 从JSON/YAML格式文件中解析服务实例信息, 兼容 {"instance": {...}} 格式
+func parseInstanceFile(path string) (*meta.InstanceInfo, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    data, err = yamlToJson(data)
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to parse instance file %s: %v", path, err))
+    }
+    wrapper := make(map[string]json.RawMessage)
+    if err = json.Unmarshal(data, &wrapper); err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to parse instance file %s: %v", path, err))
+    }
+    if raw, ok := wrapper["instance"]; ok {
+        data = raw
+    }
+    return meta.ParseInstanceInfo(data)
+}
@@ -0,0 +1,340 @@
+// eurekactl 基于 client.HttpClient 的eureka server命令行工具
+//
+// 用法:
+//
+//	eurekactl [global flags] <command> [args]
+//
+// 配置优先级: 命令行参数 > 环境变量 > 配置文件(JSON/YAML格式, 属性名称与 meta.EurekaServer 一致)
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/client"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "io"
+    "os"
+    "sort"
+    "strings"
+)
+
+// command 子命令
+type command struct {
+    // 参数说明
+    args string
+    // 命令说明
+    summary string
+    // 参数个数范围, maxArgs 小于0时不限制
+    minArgs, maxArgs int
+    run              func(ctx *ctlContext, fs *flag.FlagSet, args []string) error
+    // 子命令参数定义, 可选
+    flags func(fs *flag.FlagSet)
+}
+
+// ctlContext 子命令执行上下文
+type ctlContext struct {
+    client  *client.HttpClient
+    server  *meta.EurekaServer
+    printer *printer
+}
+
+// usageErr 命令参数错误
+type usageErr struct {
+    message string
+}
+
+func (err *usageErr) Error() string {
+    return err.message
+}
+
+// commands 子命令列表
+var commands = map[string]*command{
+    "apps": {
+        summary: "list all apps and instances",
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.QueryApps(ctx.server)
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printApps(response.Apps)
+        },
+    },
+    "app": {
+        args:    "<APP>",
+        summary: "show instances of an app",
+        minArgs: 1, maxArgs: 1,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.QueryApp(ctx.server, args[0])
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printInstances(response.Instances)
+        },
+    },
+    "instance": {
+        args:    "[APP] <INSTANCE_ID>",
+        summary: "show an instance",
+        minArgs: 1, maxArgs: 2,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            var response *client.InstanceResponse
+            if len(args) == 1 {
+                response = ctx.client.QueryInstance(ctx.server, args[0])
+            } else {
+                response = ctx.client.QueryAppInstance(ctx.server, args[0], args[1])
+            }
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printInstance(response.Instance)
+        },
+    },
+    "vip": {
+        args:    "<VIP>",
+        summary: "list apps by vip address",
+        minArgs: 1, maxArgs: 1,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.QueryVipApps(ctx.server, args[0])
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printApps(response.Apps)
+        },
+    },
+    "svip": {
+        args:    "<SVIP>",
+        summary: "list apps by secure vip address",
+        minArgs: 1, maxArgs: 1,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.QuerySvipApps(ctx.server, args[0])
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printApps(response.Apps)
+        },
+    },
+    "register": {
+        args:    "-f <FILE> [-status STATUS]",
+        summary: "register an instance from a JSON/YAML file",
+        flags: func(fs *flag.FlagSet) {
+            fs.String("f", "", "instance file (JSON/YAML)")
+            fs.String("status", "", "override instance status")
+        },
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            file := fs.Lookup("f").Value.String()
+            if file == "" {
+                return &usageErr{message: "instance file is required: -f <FILE>"}
+            }
+            instance, err := parseInstanceFile(file)
+            if err != nil {
+                return err
+            }
+            if status := fs.Lookup("status").Value.String(); status != "" {
+                if instance.Status, err = parseStatus(status); err != nil {
+                    return err
+                }
+            }
+            response := ctx.client.Register(ctx.server, instance)
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printResult("register", instance.AppName, instance.InstanceId, response.StatusCode)
+        },
+    },
+    "heartbeat": {
+        args:    "<APP> <INSTANCE_ID>",
+        summary: "send a heartbeat",
+        minArgs: 2, maxArgs: 2,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.Heartbeat(ctx.server, args[0], args[1])
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printResult("heartbeat", args[0], args[1], response.StatusCode)
+        },
+    },
+    "status": {
+        args:    "<APP> <INSTANCE_ID> <STATUS>",
+        summary: "change instance status (UP, DOWN, STARTING, OUT_OF_SERVICE, UNKNOWN)",
+        minArgs: 3, maxArgs: 3,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            status, err := parseStatus(args[2])
+            if err != nil {
+                return err
+            }
+            response := ctx.client.ChangeStatus(ctx.server, args[0], args[1], status)
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printResult("status", args[0], args[1], response.StatusCode)
+        },
+    },
+    "metadata": {
+        args:    "<APP> <INSTANCE_ID> <KEY=VALUE>...",
+        summary: "update instance metadata",
+        minArgs: 3, maxArgs: -1,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            metadata := make(map[string]string)
+            for _, pair := range args[2:] {
+                idx := strings.Index(pair, "=")
+                if idx <= 0 {
+                    return &usageErr{message: "metadata must be KEY=VALUE: " + pair}
+                }
+                metadata[pair[:idx]] = pair[idx+1:]
+            }
+            response := ctx.client.ModifyMetadata(ctx.server, args[0], args[1], metadata)
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printResult("metadata", args[0], args[1], response.StatusCode)
+        },
+    },
+    "deregister": {
+        args:    "<APP> <INSTANCE_ID>",
+        summary: "deregister an instance",
+        minArgs: 2, maxArgs: 2,
+        run: func(ctx *ctlContext, fs *flag.FlagSet, args []string) error {
+            response := ctx.client.UnRegister(ctx.server, args[0], args[1])
+            if response.Error != nil {
+                return response.Error
+            }
+            return ctx.printer.printResult("deregister", args[0], args[1], response.StatusCode)
+        },
+    },
+}
+
+// parseStatus 解析服务实例状态
+func parseStatus(value string) (meta.InstanceStatus, error) {
+    status := meta.InstanceStatus(strings.ToUpper(strings.TrimSpace(value)))
+    switch status {
+    case meta.StatusUp, meta.StatusDown, meta.StatusStarting, meta.StatusOutOfService, meta.StatusUnknown:
+        return status, nil
+    }
+    return "", &usageErr{message: "status value is invalid: " + value}
+}
+
+// usage 输出命令帮助信息
+func usage(out io.Writer, fs *flag.FlagSet) {
+    _, _ = fmt.Fprintln(out, "Usage: eurekactl [global flags] <command> [args]")
+    _, _ = fmt.Fprintln(out, "\nCommands:")
+    names := make([]string, 0, len(commands))
+    for name := range commands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        _, _ = fmt.Fprintf(out, "  %-10s %-36s %s\n", name, commands[name].args, commands[name].summary)
+    }
+    _, _ = fmt.Fprintln(out, "\nGlobal flags:")
+    fs.SetOutput(out)
+    fs.PrintDefaults()
+    _, _ = fmt.Fprintf(out, "\nEnvironment: %s, %s, %s, %s, %s, %s\n", EnvConfig, EnvServiceUrl, EnvUsername, EnvPassword, EnvTimeoutSeconds, EnvOutput)
+}
+
+// run 执行命令, 返回进程退出码(0:成功, 1:执行失败, 2:参数错误)
+func run(args []string, stdout, stderr io.Writer) int {
+    flags := &ctlConfig{}
+    var configFile string
+    fs := flag.NewFlagSet("eurekactl", flag.ContinueOnError)
+    fs.SetOutput(io.Discard)
+    fs.StringVar(&configFile, "config", "", "config file (JSON/YAML)")
+    fs.StringVar(&flags.ServiceUrl, "server", "", "eureka server service url, comma separated")
+    fs.StringVar(&flags.Username, "username", "", "basic auth username")
+    fs.StringVar(&flags.Password, "password", "", "basic auth password")
+    fs.IntVar(&flags.ReadTimeoutSeconds, "timeout", 0, fmt.Sprintf("request timeout seconds (default %d)", DefaultTimeoutSeconds))
+    fs.StringVar(&flags.Output, "output", "", "output format: table, json (default table)")
+    if err := fs.Parse(args); err != nil {
+        if errors.Is(err, flag.ErrHelp) {
+            usage(stdout, fs)
+            return 0
+        }
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        usage(stderr, fs)
+        return 2
+    }
+    if fs.NArg() == 0 {
+        usage(stderr, fs)
+        return 2
+    }
+    name := fs.Arg(0)
+    if name == "help" {
+        usage(stdout, fs)
+        return 0
+    }
+    cmd, ok := commands[name]
+    if !ok {
+        _, _ = fmt.Fprintf(stderr, "error: unknown command: %s\n", name)
+        usage(stderr, fs)
+        return 2
+    }
+
+    // 配置优先级: 命令行参数 > 环境变量 > 配置文件
+    config := &ctlConfig{}
+    if configFile == "" {
+        configFile = os.Getenv(EnvConfig)
+    }
+    if configFile != "" {
+        if err := loadConfigFile(configFile, config); err != nil {
+            _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+            return 1
+        }
+    }
+    if err := loadConfigEnv(config); err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        return 2
+    }
+    fs.Visit(func(f *flag.Flag) {
+        switch f.Name {
+        case "server":
+            config.ServiceUrl = flags.ServiceUrl
+        case "username":
+            config.Username = flags.Username
+        case "password":
+            config.Password = flags.Password
+        case "timeout":
+            config.ReadTimeoutSeconds = flags.ReadTimeoutSeconds
+            config.ConnectTimeoutSeconds = flags.ReadTimeoutSeconds
+        case "output":
+            config.Output = flags.Output
+        }
+    })
+    if err := config.check(); err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        return 2
+    }
+
+    cmdFs := flag.NewFlagSet("eurekactl "+name, flag.ContinueOnError)
+    cmdFs.SetOutput(stderr)
+    if cmd.flags != nil {
+        cmd.flags(cmdFs)
+    }
+    if err := cmdFs.Parse(fs.Args()[1:]); err != nil {
+        if errors.Is(err, flag.ErrHelp) {
+            return 0
+        }
+        return 2
+    }
+    cmdArgs := cmdFs.Args()
+    if len(cmdArgs) < cmd.minArgs || (cmd.maxArgs >= 0 && len(cmdArgs) > cmd.maxArgs) {
+        _, _ = fmt.Fprintf(stderr, "Usage: eurekactl %s %s\n", name, cmd.args)
+        return 2
+    }
+    ctx := &ctlContext{
+        client:  &client.HttpClient{},
+        server:  &config.EurekaServer,
+        printer: &printer{out: stdout, output: config.Output},
+    }
+    if err := cmd.run(ctx, cmdFs, cmdArgs); err != nil {
+        _, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+        var ue *usageErr
+        if errors.As(err, &ue) {
+            return 2
+        }
+        return 1
+    }
+    return 0
+}
+
+func main() {
+    os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// testServer eureka server替身, 返回固定服务列表并记录请求
+type testServer struct {
+    *httptest.Server
+    mutex    sync.Mutex
+    requests []string
+    bodies   []string
+}
+
+// newTestServer 创建eureka server替身
+func newTestServer() *testServer {
+    server := &testServer{}
+    instance := map[string]interface{}{
+        "instanceId": "order-1",
+        "hostName":   "host-1",
+        "app":        "ORDER",
+        "ipAddr":     "10.0.0.1",
+        "status":     "UP",
+        "port":       map[string]interface{}{"$": 8080, "@enabled": "true"},
+        "securePort": map[string]interface{}{"$": 443, "@enabled": "false"},
+        "vipAddress": "order-vip",
+        "metadata":   map[string]string{"version": "v1"},
+    }
+    apps := map[string]interface{}{"applications": map[string]interface{}{"application": []interface{}{
+        map[string]interface{}{"name": "ORDER", "instance": []interface{}{instance}},
+    }}}
+    server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        server.mutex.Lock()
+        server.requests = append(server.requests, r.Method+" "+r.URL.RequestURI())
+        server.bodies = append(server.bodies, string(body))
+        server.mutex.Unlock()
+        if username, password, _ := r.BasicAuth(); username != "user" || password != "pass" {
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+        var ret interface{}
+        switch {
+        case r.Method == http.MethodGet && (r.URL.Path == "/apps" || r.URL.Path == "/vips/order-vip"):
+            ret = apps
+        case r.Method == http.MethodGet && r.URL.Path == "/apps/ORDER":
+            ret = map[string]interface{}{"application": map[string]interface{}{"name": "ORDER", "instance": []interface{}{instance}}}
+        case r.Method == http.MethodGet && (r.URL.Path == "/instances/order-1" || r.URL.Path == "/apps/ORDER/order-1"):
+            ret = map[string]interface{}{"instance": instance}
+        case r.Method == http.MethodPost && r.URL.Path == "/apps/ORDER":
+            w.WriteHeader(http.StatusNoContent)
+            return
+        case strings.HasPrefix(r.URL.Path, "/apps/ORDER/order-1") && r.Method != http.MethodGet:
+            w.WriteHeader(http.StatusOK)
+            return
+        default:
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        _ = json.NewEncoder(w).Encode(ret)
+    }))
+    return server
+}
+
+// lastRequest 获取最后一次请求及请求体
+func (server *testServer) lastRequest() (string, string) {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return server.requests[len(server.requests)-1], server.bodies[len(server.bodies)-1]
+}
+
+// runCtl 执行命令并返回退出码及输出
+func runCtl(args ...string) (int, string, string) {
+    stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+    code := run(args, stdout, stderr)
+    return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Query(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestServer()
+    defer server.Close()
+    global := []string{"-server", server.URL, "-username", "user", "-password", "pass"}
+
+    code, stdout, stderr := runCtl(append(global, "apps")...)
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, "INSTANCE ID")
+    ast.Contains(stdout, "order-1")
+    ast.Contains(stdout, "8080")
+
+    code, stdout, stderr = runCtl(append(global, "-output", "json", "apps")...)
+    ast.Equal(0, code, stderr)
+    apps := make([]*meta.AppInfo, 0)
+    ast.Nil(json.Unmarshal([]byte(stdout), &apps))
+    ast.Len(apps, 1)
+    ast.Equal("order-1", apps[0].Instances[0].InstanceId)
+
+    code, stdout, stderr = runCtl(append(global, "app", "ORDER")...)
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, "order-vip")
+
+    code, stdout, stderr = runCtl(append(global, "instance", "order-1")...)
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, "metadata.version")
+
+    code, stdout, stderr = runCtl(append(global, "-output", "json", "instance", "ORDER", "order-1")...)
+    ast.Equal(0, code, stderr)
+    instance := &meta.InstanceInfo{}
+    ast.Nil(json.Unmarshal([]byte(stdout), instance))
+    ast.Equal("10.0.0.1", instance.IpAddr)
+
+    code, stdout, stderr = runCtl(append(global, "vip", "order-vip")...)
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, "order-1")
+
+    code, _, stderr = runCtl(append(global, "svip", "missing")...)
+    ast.Equal(1, code)
+    ast.Contains(stderr, "404")
+
+    code, _, stderr = runCtl("-server", server.URL, "apps")
+    ast.Equal(1, code)
+    ast.Contains(stderr, "401")
+}
+
+func TestRun_Modify(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestServer()
+    defer server.Close()
+    global := []string{"-server", server.URL, "-username", "user", "-password", "pass"}
+
+    file := filepath.Join(t.TempDir(), "instance.yaml")
+    ast.Nil(os.WriteFile(file, []byte(`
+instance:
+  instanceId: order-1
+  app: ORDER
+  hostName: host-1
+  ipAddr: 10.0.0.1
+  port:
+    $: 8080
+    "@enabled": "true"
+  metadata:
+    version: v2
+`), 0644))
+    code, stdout, stderr := runCtl(append(global, "register", "-f", file, "-status", "up")...)
+    ast.Equal(0, code, stderr)
+    ast.Equal("register ORDER/order-1: OK (204)\n", stdout)
+    request, body := server.lastRequest()
+    ast.Equal("POST /apps/ORDER", request)
+    registered := make(map[string]*meta.InstanceInfo)
+    ast.Nil(json.Unmarshal([]byte(body), &registered))
+    ast.Equal(meta.StatusUp, registered["instance"].Status)
+    ast.Equal(8080, registered["instance"].Port.Port)
+    ast.Equal("v2", registered["instance"].Metadata["version"])
+
+    code, _, stderr = runCtl(append(global, "heartbeat", "ORDER", "order-1")...)
+    ast.Equal(0, code, stderr)
+    request, _ = server.lastRequest()
+    ast.Equal("PUT /apps/ORDER/order-1", request)
+
+    code, stdout, stderr = runCtl(append(global, "-output", "json", "status", "ORDER", "order-1", "out_of_service")...)
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, `"action": "status"`)
+    request, _ = server.lastRequest()
+    ast.Equal("PUT /apps/ORDER/order-1/status?value=OUT_OF_SERVICE", request)
+
+    code, _, stderr = runCtl(append(global, "metadata", "ORDER", "order-1", "version=v3")...)
+    ast.Equal(0, code, stderr)
+    request, _ = server.lastRequest()
+    ast.Equal("PUT /apps/ORDER/order-1/metadata?version=v3", request)
+
+    code, _, stderr = runCtl(append(global, "deregister", "ORDER", "order-1")...)
+    ast.Equal(0, code, stderr)
+    request, _ = server.lastRequest()
+    ast.Equal("DELETE /apps/ORDER/order-1", request)
+
+    // 参数错误
+    code, _, _ = runCtl(append(global, "status", "ORDER", "order-1", "BAD")...)
+    ast.Equal(2, code)
+    code, _, _ = runCtl(append(global, "metadata", "ORDER", "order-1", "novalue")...)
+    ast.Equal(2, code)
+    code, _, _ = runCtl(append(global, "register")...)
+    ast.Equal(2, code)
+    code, _, _ = runCtl(append(global, "heartbeat", "ORDER")...)
+    ast.Equal(2, code)
+    code, _, stderr = runCtl(append(global, "unknown")...)
+    ast.Equal(2, code)
+    ast.Contains(stderr, "unknown command")
+    code, stdout, _ = runCtl("help")
+    ast.Equal(0, code)
+    ast.Contains(stdout, "deregister")
+}
+
+func TestRun_Config(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestServer()
+    defer server.Close()
+
+    // 配置文件
+    file := filepath.Join(t.TempDir(), "eurekactl.yaml")
+    ast.Nil(os.WriteFile(file, []byte("service-url: http://127.0.0.1:1\nusername: user\npassword: wrong\noutput: json\n"), 0644))
+    t.Setenv(EnvConfig, file)
+    // 环境变量优先于配置文件
+    t.Setenv(EnvServiceUrl, server.URL)
+    t.Setenv(EnvPassword, "pass")
+    code, stdout, stderr := runCtl("apps")
+    ast.Equal(0, code, stderr)
+    ast.True(strings.HasPrefix(stdout, "["))
+
+    // 命令行参数优先于环境变量
+    code, stdout, stderr = runCtl("-output", "table", "apps")
+    ast.Equal(0, code, stderr)
+    ast.Contains(stdout, "INSTANCE ID")
+
+    t.Setenv(EnvOutput, "xml")
+    code, _, stderr = runCtl("apps")
+    ast.Equal(2, code)
+    ast.Contains(stderr, "output format is invalid")
+
+    t.Setenv(EnvServiceUrl, "")
+    t.Setenv(EnvConfig, "")
+    code, _, stderr = runCtl("apps")
+    ast.Equal(2, code)
+    ast.Contains(stderr, "service url is required")
+}
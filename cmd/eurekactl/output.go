@@ -0,0 +1,131 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+    "text/tabwriter"
+)
+
+// printer 命令结果输出
+type printer struct {
+    out    io.Writer
+    output string
+}
+
+// printJson 输出JSON格式内容
+func (p *printer) printJson(value interface{}) error {
+    data, err := json.MarshalIndent(value, "", "  ")
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintln(p.out, string(data))
+    return err
+}
+
+// printTable 输出表格
+func (p *printer) printTable(header []string, rows [][]string) error {
+    w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+    _, _ = fmt.Fprintln(w, strings.Join(header, "\t"))
+    for _, row := range rows {
+        _, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+    }
+    return w.Flush()
+}
+
+// printApps 输出服务列表
+func (p *printer) printApps(apps []*meta.AppInfo) error {
+    if p.output == OutputJson {
+        return p.printJson(apps)
+    }
+    instances := make([]*meta.InstanceInfo, 0)
+    for _, app := range apps {
+        instances = append(instances, app.Instances...)
+    }
+    return p.printInstances(instances)
+}
+
+// printInstances 输出服务实例列表
+func (p *printer) printInstances(instances []*meta.InstanceInfo) error {
+    if p.output == OutputJson {
+        return p.printJson(instances)
+    }
+    sorted := append(make([]*meta.InstanceInfo, 0, len(instances)), instances...)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        if sorted[i].AppName != sorted[j].AppName {
+            return sorted[i].AppName < sorted[j].AppName
+        }
+        return sorted[i].InstanceId < sorted[j].InstanceId
+    })
+    rows := make([][]string, 0, len(sorted))
+    for _, instance := range sorted {
+        rows = append(rows, []string{
+            instance.AppName,
+            instance.InstanceId,
+            string(instance.Status),
+            instance.HostName,
+            instance.IpAddr,
+            portString(instance.Port),
+            portString(instance.SecurePort),
+            instance.VipAddress,
+        })
+    }
+    return p.printTable([]string{"APP", "INSTANCE ID", "STATUS", "HOST", "IP", "PORT", "SECURE PORT", "VIP"}, rows)
+}
+
+// printInstance 输出服务实例详情
+func (p *printer) printInstance(instance *meta.InstanceInfo) error {
+    if p.output == OutputJson {
+        return p.printJson(instance)
+    }
+    rows := [][]string{
+        {"app", instance.AppName},
+        {"instanceId", instance.InstanceId},
+        {"status", string(instance.Status)},
+        {"overriddenStatus", string(instance.OverriddenStatus)},
+        {"hostName", instance.HostName},
+        {"ipAddr", instance.IpAddr},
+        {"port", portString(instance.Port)},
+        {"securePort", portString(instance.SecurePort)},
+        {"vipAddress", instance.VipAddress},
+        {"secureVipAddress", instance.SecureVipAddress},
+        {"homePageUrl", instance.HomePageUrl},
+        {"statusPageUrl", instance.StatusPageUrl},
+        {"healthCheckUrl", instance.HealthCheckUrl},
+    }
+    keys := make([]string, 0, len(instance.Metadata))
+    for key := range instance.Metadata {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        rows = append(rows, []string{"metadata." + key, instance.Metadata[key]})
+    }
+    return p.printTable([]string{"KEY", "VALUE"}, rows)
+}
+
+// printResult 输出变更类命令处理结果
+func (p *printer) printResult(action, appName, instanceId string, statusCode int) error {
+    if p.output == OutputJson {
+        return p.printJson(map[string]interface{}{
+            "action":     action,
+            "app":        appName,
+            "instanceId": instanceId,
+            "statusCode": statusCode,
+        })
+    }
+    _, err := fmt.Fprintf(p.out, "%s %s/%s: OK (%d)\n", action, appName, instanceId, statusCode)
+    return err
+}
+
+// portString 端口信息, 未开启时返回 "-"
+func portString(port *meta.PortWrapper) string {
+    if port == nil || !port.IsEnabled() {
+        return "-"
+    }
+    return strconv.Itoa(port.Port)
+}
@@ -0,0 +1,222 @@
+package grpcresolver
+
+import (
+    "errors"
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/client"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "google.golang.org/grpc/attributes"
+    "google.golang.org/grpc/resolver"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// Scheme eureka服务名称解析协议, 目标地址格式: eureka:///APP-NAME
+const Scheme = "eureka"
+
+// DefaultPortMetadataKey 默认从服务实例元数据中获取gRPC端口的key
+const DefaultPortMetadataKey = "grpc.port"
+
+// attributeKey 服务实例属性key类型
+type attributeKey string
+
+const (
+    // InstanceIdKey 地址属性: 服务实例ID
+    InstanceIdKey attributeKey = "eureka.instanceId"
+    // ZoneKey 地址属性: 服务实例归属zone
+    ZoneKey attributeKey = "eureka.zone"
+    // MetadataKey 地址属性: 服务实例元数据( Metadata )
+    MetadataKey attributeKey = "eureka.metadata"
+)
+
+// Metadata 服务实例元数据(作为地址属性值时可比较)
+type Metadata map[string]string
+
+// Equal 判断元数据是否相同
+func (m Metadata) Equal(o any) bool {
+    om, ok := o.(Metadata)
+    if !ok || len(m) != len(om) {
+        return false
+    }
+    for k, v := range m {
+        if ov, ok := om[k]; !ok || ov != v {
+            return false
+        }
+    }
+    return true
+}
+
+// GetMetadata 从地址属性中获取服务实例元数据
+func GetMetadata(address resolver.Address) Metadata {
+    if address.Attributes == nil {
+        return nil
+    }
+    m, _ := address.Attributes.Value(MetadataKey).(Metadata)
+    return m
+}
+
+// Builder 基于 *client.DiscoveryClient 服务发现缓存的gRPC名称解析构造器
+type Builder struct {
+    // 服务发现客户端
+    Discovery *client.DiscoveryClient
+    // 从服务实例元数据中获取gRPC端口的key, 默认: DefaultPortMetadataKey, 元数据中不存在时使用服务实例已启用端口
+    PortMetadataKey string
+    // 是否优先使用https端口
+    PreferSecurePort bool
+}
+
+// NewBuilder 根据 *client.DiscoveryClient 创建gRPC名称解析构造器
+func NewBuilder(discovery *client.DiscoveryClient) *Builder {
+    return &Builder{
+        Discovery:       discovery,
+        PortMetadataKey: DefaultPortMetadataKey,
+    }
+}
+
+// Register 根据 *client.DiscoveryClient 创建gRPC名称解析构造器并全局注册
+func Register(discovery *client.DiscoveryClient) *Builder {
+    builder := NewBuilder(discovery)
+    resolver.Register(builder)
+    return builder
+}
+
+// Scheme 名称解析协议
+func (builder *Builder) Scheme() string {
+    return Scheme
+}
+
+// Build 创建gRPC名称解析器
+func (builder *Builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+    if builder.Discovery == nil {
+        return nil, errors.New("DiscoveryClient is nil")
+    }
+    appName := strings.Trim(target.Endpoint(), "/")
+    if appName == "" {
+        return nil, errors.New(fmt.Sprintf("the app name of target is empty: %s", target.URL.String()))
+    }
+    r := &eurekaResolver{
+        builder: builder,
+        appName: appName,
+        cc:      cc,
+    }
+    r.unsubscribe = builder.Discovery.Subscribe(func(Apps map[string][]*meta.AppInfo) {
+        r.resolve(Apps)
+    })
+    r.resolve(builder.Discovery.Apps)
+    return r, nil
+}
+
+// eurekaResolver gRPC名称解析器
+type eurekaResolver struct {
+    builder     *Builder
+    appName     string
+    cc          resolver.ClientConn
+    unsubscribe func()
+    mutex       sync.Mutex
+    last        []string
+    closed      bool
+}
+
+// ResolveNow 根据当前服务发现缓存重新解析
+func (r *eurekaResolver) ResolveNow(resolver.ResolveNowOptions) {
+    r.mutex.Lock()
+    r.last = nil
+    r.mutex.Unlock()
+    r.resolve(r.builder.Discovery.Apps)
+}
+
+// Close 关闭名称解析器
+func (r *eurekaResolver) Close() {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+    r.closed = true
+    r.unsubscribe()
+}
+
+// resolve 解析可用服务实例地址并推送更新(地址列表无变化时不推送)
+func (r *eurekaResolver) resolve(Apps map[string][]*meta.AppInfo) {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+    if r.closed {
+        return
+    }
+    app, err := r.builder.Discovery.FilterApp(Apps, r.appName)
+    if err != nil {
+        r.last = nil
+        r.cc.ReportError(errors.New(fmt.Sprintf("failed to resolve app: %s, error: %v", r.appName, err)))
+        return
+    }
+    addresses, keys := make([]resolver.Address, 0), make([]string, 0)
+    for _, instance := range app.Instances {
+        addr, ok := r.builder.address(instance)
+        if !ok {
+            continue
+        }
+        keys = append(keys, addressKey(addr, instance))
+        addresses = append(addresses, resolver.Address{
+            Addr:       addr,
+            ServerName: instance.HostName,
+            Attributes: attributes.New(InstanceIdKey, instance.InstanceId).
+                WithValue(ZoneKey, instance.Zone).
+                WithValue(MetadataKey, Metadata(instance.Metadata)),
+        })
+    }
+    if len(addresses) == 0 {
+        r.last = nil
+        r.cc.ReportError(errors.New(fmt.Sprintf("no available address found for app: %s", r.appName)))
+        return
+    }
+    sort.Slice(addresses, func(i, j int) bool {
+        return addresses[i].Addr < addresses[j].Addr
+    })
+    sort.Strings(keys)
+    if r.last != nil && strings.Join(r.last, ",") == strings.Join(keys, ",") {
+        return
+    }
+    if err = r.cc.UpdateState(resolver.State{Addresses: addresses}); err == nil {
+        r.last = keys
+    }
+}
+
+// addressKey 地址唯一标识(用于判断地址列表是否变化)
+func addressKey(addr string, instance *meta.InstanceInfo) string {
+    pairs := make([]string, 0, len(instance.Metadata))
+    for k, v := range instance.Metadata {
+        pairs = append(pairs, k+"="+v)
+    }
+    sort.Strings(pairs)
+    return addr + "|" + instance.InstanceId + "|" + instance.Zone + "|" + strings.Join(pairs, "&")
+}
+
+// address 获取服务实例gRPC地址
+func (builder *Builder) address(instance *meta.InstanceInfo) (string, bool) {
+    host := instance.HostName
+    if host == "" {
+        host = instance.IpAddr
+    }
+    if host == "" {
+        return "", false
+    }
+    key := builder.PortMetadataKey
+    if key == "" {
+        key = DefaultPortMetadataKey
+    }
+    if value, ok := instance.Metadata[key]; ok {
+        if port, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && port > 0 {
+            return net.JoinHostPort(host, strconv.Itoa(port)), true
+        }
+    }
+    ports := []*meta.PortWrapper{instance.Port, instance.SecurePort}
+    if builder.PreferSecurePort {
+        ports = []*meta.PortWrapper{instance.SecurePort, instance.Port}
+    }
+    for _, port := range ports {
+        if port != nil && port.IsEnabled() {
+            return net.JoinHostPort(host, strconv.Itoa(port.Port)), true
+        }
+    }
+    return "", false
+}
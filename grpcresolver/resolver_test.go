@@ -0,0 +1,127 @@
+package grpcresolver
+
+import (
+    "fmt"
+    "github.com/jiashunx/eureka-client-go/client"
+    "github.com/jiashunx/eureka-client-go/meta"
+    "github.com/stretchr/testify/assert"
+    "google.golang.org/grpc/resolver"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "sync"
+    "testing"
+)
+
+// testClientConn 记录名称解析结果的 resolver.ClientConn
+type testClientConn struct {
+    resolver.ClientConn
+    mutex  sync.Mutex
+    states []resolver.State
+    errs   []error
+}
+
+func (cc *testClientConn) UpdateState(state resolver.State) error {
+    cc.mutex.Lock()
+    defer cc.mutex.Unlock()
+    cc.states = append(cc.states, state)
+    return nil
+}
+
+func (cc *testClientConn) ReportError(err error) {
+    cc.mutex.Lock()
+    defer cc.mutex.Unlock()
+    cc.errs = append(cc.errs, err)
+}
+
+// testAppsJson 模拟eureka server返回的服务列表
+func testAppsJson(instances ...string) string {
+    body := ""
+    for idx, instance := range instances {
+        if idx > 0 {
+            body += ","
+        }
+        body += instance
+    }
+    return `{"applications":{"application":[{"name":"GRPC-APP","instance":[` + body + `]}]}}`
+}
+
+// testInstanceJson 模拟eureka server返回的服务实例
+func testInstanceJson(instanceId, host string, port int, status meta.InstanceStatus, grpcPort string) string {
+    metadata := `{}`
+    if grpcPort != "" {
+        metadata = fmt.Sprintf(`{"grpc.port":"%s"}`, grpcPort)
+    }
+    return fmt.Sprintf(`{"instanceId":"%s","hostName":"%s","app":"GRPC-APP","ipAddr":"%s","status":"%s",`+
+        `"port":{"$":%d,"@enabled":"true"},"securePort":{"$":443,"@enabled":"false"},"metadata":%s}`,
+        instanceId, host, host, status, port, metadata)
+}
+
+func TestBuilder_Build(t *testing.T) {
+    ast := assert.New(t)
+    var mutex sync.Mutex
+    apps := testAppsJson(
+        testInstanceJson("grpc-1", "10.0.0.1", 8080, meta.StatusUp, "9090"),
+        testInstanceJson("grpc-2", "10.0.0.2", 8080, meta.StatusDown, ""),
+    )
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mutex.Lock()
+        defer mutex.Unlock()
+        w.Header().Set("Content-Type", "application/json")
+        _, _ = w.Write([]byte(apps))
+    }))
+    defer server.Close()
+    config := &meta.EurekaConfig{
+        ClientConfig: &meta.ClientConfig{ServiceUrlOfDefaultZone: server.URL},
+    }
+    ast.Nil(config.Check())
+    discovery := &client.DiscoveryClient{HttpClient: &client.HttpClient{}, Config: config, Apps: map[string][]*meta.AppInfo{}}
+
+    builder := NewBuilder(discovery)
+    ast.Equal(Scheme, builder.Scheme())
+    cc := &testClientConn{}
+    r, err := builder.Build(resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/grpc-app"}}, cc, resolver.BuildOptions{})
+    ast.Nilf(err, "%v", err)
+    defer r.Close()
+    // 缓存为空时上报错误
+    ast.Equal(1, len(cc.errs))
+    ast.Equal(0, len(cc.states))
+
+    _, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(1, len(cc.states))
+    ast.Equal(1, len(cc.states[0].Addresses))
+    ast.Equal("10.0.0.1:9090", cc.states[0].Addresses[0].Addr)
+    ast.Equal("grpc-1", cc.states[0].Addresses[0].Attributes.Value(InstanceIdKey))
+    ast.Equal("9090", GetMetadata(cc.states[0].Addresses[0])["grpc.port"])
+
+    // 地址列表无变化时不推送
+    _, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(1, len(cc.states))
+
+    // 服务实例状态变化后推送新地址列表
+    mutex.Lock()
+    apps = testAppsJson(
+        testInstanceJson("grpc-1", "10.0.0.1", 8080, meta.StatusUp, "9090"),
+        testInstanceJson("grpc-2", "10.0.0.2", 8080, meta.StatusUp, ""),
+    )
+    mutex.Unlock()
+    _, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(2, len(cc.states))
+    ast.Equal(2, len(cc.states[1].Addresses))
+    ast.Equal("10.0.0.2:8080", cc.states[1].Addresses[1].Addr)
+
+    // 关闭后不再推送
+    r.Close()
+    mutex.Lock()
+    apps = testAppsJson(testInstanceJson("grpc-3", "10.0.0.3", 8080, meta.StatusUp, ""))
+    mutex.Unlock()
+    _, err = discovery.Discovery0()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(2, len(cc.states))
+
+    _, err = builder.Build(resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/"}}, cc, resolver.BuildOptions{})
+    ast.NotNil(err)
+}
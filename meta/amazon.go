@@ -0,0 +1,233 @@
+package meta
+
+import (
+    "context"
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "strings"
+    "time"
+)
+
+var (
+    AmazonDataCenterClass  = "com.netflix.appinfo.AmazonInfo"
+    AmazonDataCenterName   = "Amazon"
+    DefaultImdsEndpoint    = "http://169.254.169.254"
+    DefaultImdsTimeout     = 2 * time.Second
+    DefaultImdsTokenTTL    = 21600
+    imdsTokenPath          = "/latest/api/token"
+    imdsMetadataPathPrefix = "/latest/meta-data/"
+)
+
+// AmazonInfo AWS数据中心元数据(与Java版本 AmazonInfo.MetaDataKey 一致)
+type AmazonInfo struct {
+    InstanceId       string `json:"instance-id,omitempty" xml:"instance-id,omitempty"`
+    AvailabilityZone string `json:"availability-zone,omitempty" xml:"availability-zone,omitempty"`
+    PublicHostname   string `json:"public-hostname,omitempty" xml:"public-hostname,omitempty"`
+    PublicIpv4       string `json:"public-ipv4,omitempty" xml:"public-ipv4,omitempty"`
+    LocalHostname    string `json:"local-hostname,omitempty" xml:"local-hostname,omitempty"`
+    LocalIpv4        string `json:"local-ipv4,omitempty" xml:"local-ipv4,omitempty"`
+    AmiId            string `json:"ami-id,omitempty" xml:"ami-id,omitempty"`
+    InstanceType     string `json:"instance-type,omitempty" xml:"instance-type,omitempty"`
+}
+
+// Copy 复制副本
+func (info *AmazonInfo) Copy() *AmazonInfo {
+    if info == nil {
+        return nil
+    }
+    copied := *info
+    return &copied
+}
+
+// AmazonDataCenterInfo 根据AWS数据中心元数据构造数据中心信息
+func AmazonDataCenterInfo(info *AmazonInfo) *DataCenterInfo {
+    return &DataCenterInfo{
+        Class:    AmazonDataCenterClass,
+        Name:     AmazonDataCenterName,
+        Metadata: info.Copy(),
+    }
+}
+
+// IsAmazon 是否AWS数据中心
+func (dc *DataCenterInfo) IsAmazon() bool {
+    return dc != nil && dc.Name == AmazonDataCenterName
+}
+
+// ToJson 对象转json
+func (dc *DataCenterInfo) ToJson() ([]byte, error) {
+    return json.Marshal(dc)
+}
+
+// ToXml 对象转xml(与eureka server xml格式一致: <dataCenterInfo class="..."><name>...</name><metadata>...</metadata></dataCenterInfo>)
+func (dc *DataCenterInfo) ToXml() ([]byte, error) {
+    return xml.Marshal(dc)
+}
+
+// ParseDataCenterInfo 从json中解析数据中心信息
+func ParseDataCenterInfo(data []byte) (dc *DataCenterInfo, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            dc = nil
+            err = errors.New(fmt.Sprintf("ParseDataCenterInfo, recover error: %v", rc))
+        }
+    }()
+    dc = &DataCenterInfo{}
+    if err = json.Unmarshal(data, dc); err != nil {
+        return nil, err
+    }
+    return dc, nil
+}
+
+// ParseDataCenterInfoXml 从xml中解析数据中心信息
+func ParseDataCenterInfoXml(data []byte) (dc *DataCenterInfo, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            dc = nil
+            err = errors.New(fmt.Sprintf("ParseDataCenterInfoXml, recover error: %v", rc))
+        }
+    }()
+    dc = &DataCenterInfo{}
+    if err = xml.Unmarshal(data, dc); err != nil {
+        return nil, err
+    }
+    return dc, nil
+}
+
+// AmazonInfoProvider AWS数据中心元数据提供者
+type AmazonInfoProvider interface {
+    // AmazonInfo 获取AWS数据中心元数据
+    AmazonInfo(ctx context.Context) (*AmazonInfo, error)
+}
+
+// ImdsAmazonInfoProvider 从EC2实例元数据服务(IMDS)获取AWS数据中心元数据, 优先使用IMDSv2(token), 获取token失败时回退至IMDSv1
+type ImdsAmazonInfoProvider struct {
+    // IMDS服务地址, 默认: DefaultImdsEndpoint
+    Endpoint string
+    // 单次请求超时时长, 默认: DefaultImdsTimeout
+    Timeout time.Duration
+    // 自定义http客户端(可选)
+    Client *http.Client
+}
+
+// AmazonInfo 从IMDS获取AWS数据中心元数据(instance-id及availability-zone必须存在, 其余属性不存在时为空)
+func (provider *ImdsAmazonInfoProvider) AmazonInfo(ctx context.Context) (*AmazonInfo, error) {
+    if ctx == nil {
+        ctx = context.Background()
+    }
+    token := provider.token(ctx)
+    info := &AmazonInfo{}
+    fields := []struct {
+        path     string
+        value    *string
+        required bool
+    }{
+        {"instance-id", &info.InstanceId, true},
+        {"placement/availability-zone", &info.AvailabilityZone, true},
+        {"public-hostname", &info.PublicHostname, false},
+        {"public-ipv4", &info.PublicIpv4, false},
+        {"local-hostname", &info.LocalHostname, false},
+        {"local-ipv4", &info.LocalIpv4, false},
+        {"ami-id", &info.AmiId, false},
+        {"instance-type", &info.InstanceType, false},
+    }
+    for _, field := range fields {
+        value, found, err := provider.get(ctx, token, field.path)
+        if err != nil {
+            return nil, err
+        }
+        if !found && field.required {
+            return nil, errors.New(fmt.Sprintf("ImdsAmazonInfoProvider.AmazonInfo, metadata not found: %s", field.path))
+        }
+        *field.value = value
+    }
+    return info, nil
+}
+
+// token 获取IMDSv2 token, 失败时返回空(回退至IMDSv1)
+func (provider *ImdsAmazonInfoProvider) token(ctx context.Context) string {
+    request, err := provider.newRequest(ctx, http.MethodPut, imdsTokenPath)
+    if err != nil {
+        return ""
+    }
+    defer request.cancel()
+    request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", fmt.Sprintf("%d", DefaultImdsTokenTTL))
+    body, status, err := provider.do(request.Request)
+    if err != nil || status != http.StatusOK {
+        return ""
+    }
+    return strings.TrimSpace(body)
+}
+
+// get 获取指定路径的元数据(404时返回未找到)
+func (provider *ImdsAmazonInfoProvider) get(ctx context.Context, token, path string) (string, bool, error) {
+    request, err := provider.newRequest(ctx, http.MethodGet, imdsMetadataPathPrefix+path)
+    if err != nil {
+        return "", false, err
+    }
+    defer request.cancel()
+    if token != "" {
+        request.Header.Set("X-aws-ec2-metadata-token", token)
+    }
+    body, status, err := provider.do(request.Request)
+    if err != nil {
+        return "", false, err
+    }
+    switch status {
+    case http.StatusOK:
+        return strings.TrimSpace(body), true, nil
+    case http.StatusNotFound:
+        return "", false, nil
+    default:
+        return "", false, errors.New(fmt.Sprintf("ImdsAmazonInfoProvider.get, the http response code is incorrect, path: %s, actual: %d", path, status))
+    }
+}
+
+// imdsRequest 附带超时取消函数的IMDS请求
+type imdsRequest struct {
+    *http.Request
+    cancel context.CancelFunc
+}
+
+// newRequest 创建IMDS请求(附带单次请求超时)
+func (provider *ImdsAmazonInfoProvider) newRequest(ctx context.Context, method, path string) (*imdsRequest, error) {
+    endpoint := strings.TrimRight(strings.TrimSpace(provider.Endpoint), "/")
+    if endpoint == "" {
+        endpoint = DefaultImdsEndpoint
+    }
+    timeout := provider.Timeout
+    if timeout <= 0 {
+        timeout = DefaultImdsTimeout
+    }
+    ctx, cancel := context.WithTimeout(ctx, timeout)
+    request, err := http.NewRequestWithContext(ctx, method, endpoint+path, nil)
+    if err != nil {
+        cancel()
+        return nil, err
+    }
+    return &imdsRequest{Request: request, cancel: cancel}, nil
+}
+
+// do 发送IMDS请求并读取响应内容
+func (provider *ImdsAmazonInfoProvider) do(request *http.Request) (string, int, error) {
+    client := provider.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    response, err := client.Do(request)
+    if err != nil {
+        return "", 0, err
+    }
+    defer func() {
+        _ = response.Body.Close()
+    }()
+    body, err := ioutil.ReadAll(io.LimitReader(response.Body, 64*1024))
+    if err != nil {
+        return "", response.StatusCode, err
+    }
+    return string(body), response.StatusCode, nil
+}
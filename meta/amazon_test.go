@@ -0,0 +1,179 @@
+package meta
+
+import (
+    "context"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+)
+
+// testImdsServer 测试使用的IMDS服务
+type testImdsServer struct {
+    *httptest.Server
+    mutex         sync.Mutex
+    metadata      map[string]string
+    tokenDisabled bool
+    requests      []string
+}
+
+// newTestImdsServer 创建并启动测试使用的IMDS服务
+func newTestImdsServer(metadata map[string]string, tokenDisabled bool) *testImdsServer {
+    server := &testImdsServer{metadata: metadata, tokenDisabled: tokenDisabled}
+    server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        server.mutex.Lock()
+        defer server.mutex.Unlock()
+        server.requests = append(server.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-aws-ec2-metadata-token"))
+        if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+            if server.tokenDisabled || r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+                w.WriteHeader(http.StatusForbidden)
+                return
+            }
+            _, _ = w.Write([]byte("test-token"))
+            return
+        }
+        if !server.tokenDisabled && r.Header.Get("X-aws-ec2-metadata-token") != "test-token" {
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+        value, ok := server.metadata[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/")]
+        if r.Method != http.MethodGet || !ok {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        _, _ = w.Write([]byte(value + "\n"))
+    }))
+    return server
+}
+
+// Requests 获取已接收的请求列表(格式: METHOD PATH TOKEN)
+func (server *testImdsServer) Requests() []string {
+    server.mutex.Lock()
+    defer server.mutex.Unlock()
+    return append(make([]string, 0), server.requests...)
+}
+
+var testImdsMetadata = map[string]string{
+    "instance-id":                 "i-0123456789abcdef0",
+    "placement/availability-zone": "us-east-1a",
+    "public-hostname":             "ec2-54-1-2-3.compute-1.amazonaws.com",
+    "public-ipv4":                 "54.1.2.3",
+    "local-hostname":              "ip-10-0-0-1.ec2.internal",
+    "local-ipv4":                  "10.0.0.1",
+    "ami-id":                      "ami-0abcdef1234567890",
+    "instance-type":               "t3.micro",
+}
+
+func TestImdsAmazonInfoProvider(t *testing.T) {
+    ast := assert.New(t)
+    // IMDSv2
+    server := newTestImdsServer(testImdsMetadata, false)
+    defer server.Close()
+    provider := &ImdsAmazonInfoProvider{Endpoint: server.URL + "/"}
+    info, err := provider.AmazonInfo(context.Background())
+    ast.Nilf(err, "%v", err)
+    ast.Equal(&AmazonInfo{
+        InstanceId:       "i-0123456789abcdef0",
+        AvailabilityZone: "us-east-1a",
+        PublicHostname:   "ec2-54-1-2-3.compute-1.amazonaws.com",
+        PublicIpv4:       "54.1.2.3",
+        LocalHostname:    "ip-10-0-0-1.ec2.internal",
+        LocalIpv4:        "10.0.0.1",
+        AmiId:            "ami-0abcdef1234567890",
+        InstanceType:     "t3.micro",
+    }, info)
+    requests := server.Requests()
+    ast.Equal("PUT /latest/api/token ", requests[0])
+    ast.Equal("GET /latest/meta-data/instance-id test-token", requests[1])
+
+    // 获取token失败时回退至IMDSv1, 非必须属性不存在时为空
+    metadata := map[string]string{"instance-id": "i-1", "placement/availability-zone": "us-east-1b"}
+    v1 := newTestImdsServer(metadata, true)
+    defer v1.Close()
+    info, err = (&ImdsAmazonInfoProvider{Endpoint: v1.URL}).AmazonInfo(context.TODO())
+    ast.Nilf(err, "%v", err)
+    ast.Equal(&AmazonInfo{InstanceId: "i-1", AvailabilityZone: "us-east-1b"}, info)
+    ast.Equal("GET /latest/meta-data/instance-id ", v1.Requests()[1])
+
+    // 必须属性不存在
+    delete(metadata, "placement/availability-zone")
+    _, err = (&ImdsAmazonInfoProvider{Endpoint: v1.URL}).AmazonInfo(context.Background())
+    ast.NotNil(err)
+    ast.Contains(err.Error(), "placement/availability-zone")
+
+    // IMDS服务不可用
+    closed := httptest.NewServer(http.NotFoundHandler())
+    closed.Close()
+    _, err = (&ImdsAmazonInfoProvider{Endpoint: closed.URL}).AmazonInfo(context.Background())
+    ast.NotNil(err)
+}
+
+func TestDataCenterInfo_Serialization(t *testing.T) {
+    ast := assert.New(t)
+    dc := AmazonDataCenterInfo(&AmazonInfo{InstanceId: "i-1", AvailabilityZone: "us-east-1a", LocalIpv4: "10.0.0.1", AmiId: "ami-1"})
+    ast.True(dc.IsAmazon())
+    ast.False(DefaultDataCenterInfo().IsAmazon())
+
+    data, err := dc.ToJson()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(`{"@class":"com.netflix.appinfo.AmazonInfo","name":"Amazon","metadata":{"instance-id":"i-1","availability-zone":"us-east-1a","local-ipv4":"10.0.0.1","ami-id":"ami-1"}}`, string(data))
+    parsed, err := ParseDataCenterInfo(data)
+    ast.Nilf(err, "%v", err)
+    ast.Equal(dc.Metadata, parsed.Metadata)
+    ast.Equal(dc.Class, parsed.Class)
+
+    data, err = dc.ToXml()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(`<dataCenterInfo class="com.netflix.appinfo.AmazonInfo"><name>Amazon</name><metadata><instance-id>i-1</instance-id><availability-zone>us-east-1a</availability-zone><local-ipv4>10.0.0.1</local-ipv4><ami-id>ami-1</ami-id></metadata></dataCenterInfo>`, string(data))
+    parsed, err = ParseDataCenterInfoXml(data)
+    ast.Nilf(err, "%v", err)
+    ast.Equal(dc.Metadata, parsed.Metadata)
+    ast.Equal(dc.Name, parsed.Name)
+
+    // 默认数据中心信息不输出metadata
+    data, err = DefaultDataCenterInfo().ToJson()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(`{"@class":"com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo","name":"MyOwn"}`, string(data))
+    data, err = DefaultDataCenterInfo().ToXml()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(`<dataCenterInfo class="com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo"><name>MyOwn</name></dataCenterInfo>`, string(data))
+
+    // 复制副本
+    copied := dc.Copy()
+    copied.Metadata.InstanceId = "i-2"
+    ast.Equal("i-1", dc.Metadata.InstanceId)
+    _, err = ParseDataCenterInfoXml([]byte("<dataCenterInfo"))
+    ast.NotNil(err)
+}
+
+func TestEurekaConfig_AmazonInfoProvider(t *testing.T) {
+    ast := assert.New(t)
+    server := newTestImdsServer(testImdsMetadata, false)
+    defer server.Close()
+    config := &EurekaConfig{InstanceConfig: &InstanceConfig{AmazonInfoProvider: &ImdsAmazonInfoProvider{Endpoint: server.URL}}}
+    ast.Nil(config.Check())
+    ast.True(config.DataCenterInfo.IsAmazon())
+    ast.Equal("us-east-1a", config.DataCenterInfo.Metadata.AvailabilityZone)
+
+    // 已设置数据中心信息时不请求IMDS
+    requests := len(server.Requests())
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{
+        DataCenterInfo:     AmazonDataCenterInfo(&AmazonInfo{InstanceId: "i-1"}),
+        AmazonInfoProvider: &ImdsAmazonInfoProvider{Endpoint: server.URL},
+    }}
+    ast.Nil(config.Check())
+    ast.Equal("i-1", config.DataCenterInfo.Metadata.InstanceId)
+    ast.Len(server.Requests(), requests)
+
+    // 未设置时使用默认数据中心信息
+    config = &EurekaConfig{}
+    ast.Nil(config.Check())
+    ast.Equal(DefaultDataCenterInfo(), config.DataCenterInfo)
+
+    // 获取失败
+    server.Close()
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{AmazonInfoProvider: &ImdsAmazonInfoProvider{Endpoint: server.URL}}}
+    ast.NotNil(config.Check())
+}
@@ -4,6 +4,7 @@ import (
     "encoding/json"
     "errors"
     "fmt"
+    "io"
 )
 
 // AppInfo 服务信息
@@ -77,3 +78,13 @@ func ParseAppInfo(data []byte) (app *AppInfo, err error) {
     }
     return app, nil
 }
+
+// DecodeApps 从json流中逐个解析服务列表({"applications": {"application": [...]}}),
+// 不构造完整的中间结构, 解析过程中仅缓存单个服务信息
+func DecodeApps(reader io.Reader) ([]*AppInfo, error) {
+    applications, err := DecodeApplications(reader)
+    if err != nil {
+        return nil, err
+    }
+    return applications.Apps, nil
+}
@@ -1,8 +1,12 @@
 package meta
 
 import (
+    "bytes"
+    "encoding/json"
+    "errors"
     "fmt"
     "github.com/stretchr/testify/assert"
+    "strings"
     "testing"
 )
 
@@ -65,3 +69,134 @@ var TestAppInfo = `
     ]
 }
 `
+
+func TestDecodeApps(t *testing.T) {
+    ast := assert.New(t)
+    body := `{"applications": {"versions__delta": "1", "apps__hashcode": "UP_1_", "application": [` + TestAppInfo + `, {"name": "EMPTY"}], "extra": [{"a": [1, {}]}]}}`
+    apps, err := DecodeApps(strings.NewReader(body))
+    ast.Nilf(err, "%v", err)
+    ast.Len(apps, 2)
+    ast.Equal("SPRINGBOOT278", apps[0].Name)
+    ast.Equal(18080, apps[0].Instances[0].Port.Port)
+    ast.Equal("world", apps[0].Instances[0].Metadata["hello"])
+    ast.NotNil(apps[1].Instances)
+    ast.Len(apps[1].Instances, 0)
+
+    // 与逐个序列化再解析的结果一致
+    payload := newTestAppsPayload(20, 5)
+    apps, err = DecodeApps(bytes.NewReader(payload))
+    ast.Nilf(err, "%v", err)
+    legacy, err := decodeAppsLegacy(payload)
+    ast.Nilf(err, "%v", err)
+    ast.Equal(legacy, apps)
+
+    apps, err = DecodeApps(strings.NewReader(`{"applications": {"application": []}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Len(apps, 0)
+    for body, message := range map[string]string{
+        `{}`:                                      "the query yielded no results: 'applications'",
+        `{"applications": null}`:                  "the query yielded no results: 'applications'",
+        `{"applications": {}}`:                    "the query yielded no results: 'applications'.'application'",
+        `{"applications": {"application": null}}`: "the query yielded no results: 'applications'.'application'",
+    } {
+        _, err = DecodeApps(strings.NewReader(body))
+        ast.EqualError(err, message, body)
+    }
+    for _, body := range []string{``, `[]`, `null`, `{"applications": []}`, `{"applications": {"application": {}}}`, `{"applications": {"application": [1]}}`, `{"applications": {"application": [`} {
+        _, err = DecodeApps(strings.NewReader(body))
+        ast.NotNil(err, body)
+    }
+}
+
+// BenchmarkDecodeApps 流式解析5000个服务实例
+func BenchmarkDecodeApps(b *testing.B) {
+    payload := newTestAppsPayload(100, 50)
+    b.SetBytes(int64(len(payload)))
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := DecodeApps(bytes.NewReader(payload)); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
+
+// BenchmarkDecodeAppsLegacy 解析为interface{}后逐个序列化再解析5000个服务实例(原实现)
+func BenchmarkDecodeAppsLegacy(b *testing.B) {
+    payload := newTestAppsPayload(100, 50)
+    b.SetBytes(int64(len(payload)))
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if _, err := decodeAppsLegacy(payload); err != nil {
+            b.Fatal(err)
+        }
+    }
+}
+
+// decodeAppsLegacy 原服务列表解析实现: 解析为interface{}后逐个序列化再解析
+func decodeAppsLegacy(data []byte) ([]*AppInfo, error) {
+    var ii interface{}
+    if err := json.Unmarshal(data, &ii); err != nil {
+        return nil, err
+    }
+    ij := ii.(map[string]interface{})["applications"]
+    if ij == nil {
+        return nil, errors.New("the query yielded no results: 'applications'")
+    }
+    ik := ij.(map[string]interface{})["application"]
+    if ik == nil {
+        return nil, errors.New("the query yielded no results: 'applications'.'application'")
+    }
+    apps := make([]*AppInfo, 0)
+    for _, m := range ik.([]interface{}) {
+        data, err := json.Marshal(m)
+        if err != nil {
+            return nil, err
+        }
+        app, err := ParseAppInfo(data)
+        if err != nil {
+            return nil, err
+        }
+        apps = append(apps, app)
+    }
+    return apps, nil
+}
+
+// newTestAppsPayload 构造包含appCount个服务、每个服务instanceCount个服务实例的服务列表响应
+func newTestAppsPayload(appCount, instanceCount int) []byte {
+    apps := make([]*AppInfo, 0, appCount)
+    for i := 0; i < appCount; i++ {
+        appName := fmt.Sprintf("APP-%03d", i)
+        app := &AppInfo{Name: appName, Instances: make([]*InstanceInfo, 0, instanceCount)}
+        for j := 0; j < instanceCount; j++ {
+            ip := fmt.Sprintf("10.%d.%d.%d", i/256, i%256, j)
+            instance := &InstanceInfo{
+                InstanceId: fmt.Sprintf("%s:%s:8080", ip, strings.ToLower(appName)),
+                HostName:   ip,
+                AppName:    appName,
+                IpAddr:     ip,
+                Status:     StatusUp,
+                Port:       &PortWrapper{Enabled: StrTrue, Port: 8080},
+                SecurePort: &PortWrapper{Enabled: StrFalse, Port: 443},
+                Metadata:   map[string]string{"zone": "zone1", "version": "v1", "management.port": "8081"},
+            }
+            if err := instance.Check(); err != nil {
+                panic(err)
+            }
+            app.Instances = append(app.Instances, instance)
+        }
+        apps = append(apps, app)
+    }
+    data, err := json.Marshal(map[string]interface{}{
+        "applications": map[string]interface{}{
+            "versions__delta": "1",
+            "apps__hashcode":  fmt.Sprintf("UP_%d_", appCount*instanceCount),
+            "application":     apps,
+        },
+    })
+    if err != nil {
+        panic(err)
+    }
+    return data
+}
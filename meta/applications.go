@@ -0,0 +1,240 @@
+package meta
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Applications 服务列表查询响应(applications根节点)
+type Applications struct {
+    // 服务列表版本(增量查询时有效)
+    VersionsDelta int64
+    // 服务列表hashcode(各状态服务实例数量, 如: DOWN_1_UP_5_), 用于增量同步后校验本地服务列表
+    AppsHashcode string
+    Apps         []*AppInfo
+}
+
+// applicationsJson 服务列表序列化结构
+type applicationsJson struct {
+    VersionsDelta string     `json:"versions__delta"`
+    AppsHashcode  string     `json:"apps__hashcode"`
+    Apps          []*AppInfo `json:"application"`
+}
+
+// ToJson 对象转json({"applications": {...}})
+func (applications *Applications) ToJson() ([]byte, error) {
+    apps := applications.Apps
+    if apps == nil {
+        apps = make([]*AppInfo, 0)
+    }
+    return json.Marshal(map[string]*applicationsJson{"applications": {
+        VersionsDelta: strconv.FormatInt(applications.VersionsDelta, 10),
+        AppsHashcode:  applications.AppsHashcode,
+        Apps:          apps,
+    }})
+}
+
+// ComputeHashcode 根据服务列表计算hashcode
+func (applications *Applications) ComputeHashcode() string {
+    return ComputeAppsHashcode(applications.Apps)
+}
+
+// ComputeAppsHashcode 计算服务列表hashcode, 与Java版本 Applications.getReconcileHashCode 一致:
+// 按状态名称排序后拼接 "状态_数量_"(如: DOWN_1_UP_5_), 服务实例状态为空时按UNKNOWN统计
+func ComputeAppsHashcode(apps []*AppInfo) string {
+    counts := make(map[string]int)
+    for _, app := range apps {
+        if app == nil {
+            continue
+        }
+        for _, instance := range app.Instances {
+            if instance == nil {
+                continue
+            }
+            status := instance.Status
+            if status == "" {
+                status = StatusUnknown
+            }
+            counts[string(status)]++
+        }
+    }
+    statuses := make([]string, 0, len(counts))
+    for status := range counts {
+        statuses = append(statuses, status)
+    }
+    sort.Strings(statuses)
+    builder := strings.Builder{}
+    for _, status := range statuses {
+        builder.WriteString(status)
+        builder.WriteString("_")
+        builder.WriteString(strconv.Itoa(counts[status]))
+        builder.WriteString("_")
+    }
+    return builder.String()
+}
+
+// ParseApplications 从json中解析服务列表
+func ParseApplications(data []byte) (*Applications, error) {
+    return DecodeApplications(bytes.NewReader(data))
+}
+
+// DecodeApplications 从json流中逐个解析服务列表({"applications": {"versions__delta": "1", "apps__hashcode": "UP_1_", "application": [...]}}),
+// 不构造完整的中间结构, 解析过程中仅缓存单个服务信息
+func DecodeApplications(reader io.Reader) (applications *Applications, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            applications = nil
+            err = errors.New(fmt.Sprintf("DecodeApplications, recover error: %v", rc))
+        }
+    }()
+    decoder := json.NewDecoder(reader)
+    decoder.UseNumber()
+    found := false
+    applications = &Applications{}
+    err = decodeObject(decoder, func(key string) error {
+        if key != "applications" {
+            return skipValue(decoder)
+        }
+        isNull, e := decodeNullableObject(decoder, func(key string) error {
+            var e error
+            switch key {
+            case "application":
+                applications.Apps, e = decodeAppArray(decoder)
+            case "versions__delta":
+                applications.VersionsDelta, e = decodeVersionsDelta(decoder)
+            case "apps__hashcode":
+                var hashcode *string
+                if e = decoder.Decode(&hashcode); e == nil && hashcode != nil {
+                    applications.AppsHashcode = *hashcode
+                }
+            default:
+                e = skipValue(decoder)
+            }
+            return e
+        })
+        found = !isNull
+        return e
+    })
+    if err != nil {
+        return nil, err
+    }
+    if !found {
+        return nil, errors.New("the query yielded no results: 'applications'")
+    }
+    if applications.Apps == nil {
+        return nil, errors.New("the query yielded no results: 'applications'.'application'")
+    }
+    return applications, nil
+}
+
+// decodeVersionsDelta 解析服务列表版本(兼容字符串及数字格式)
+func decodeVersionsDelta(decoder *json.Decoder) (int64, error) {
+    token, err := decoder.Token()
+    if err != nil {
+        return 0, err
+    }
+    var value string
+    switch t := token.(type) {
+    case nil:
+        return 0, nil
+    case string:
+        value = t
+    case json.Number:
+        value = t.String()
+    default:
+        return 0, errors.New(fmt.Sprintf("unexpected versions__delta: %v", token))
+    }
+    if value == "" {
+        return 0, nil
+    }
+    return strconv.ParseInt(value, 10, 64)
+}
+
+// decodeAppArray 逐个解析服务信息数组(null时返回nil)
+func decodeAppArray(decoder *json.Decoder) ([]*AppInfo, error) {
+    token, err := decoder.Token()
+    if err != nil {
+        return nil, err
+    }
+    if token == nil {
+        return nil, nil
+    }
+    if delim, ok := token.(json.Delim); !ok || delim != '[' {
+        return nil, errors.New(fmt.Sprintf("unexpected token: %v, expect: [", token))
+    }
+    apps := make([]*AppInfo, 0)
+    for decoder.More() {
+        app := &AppInfo{}
+        if err = decoder.Decode(app); err != nil {
+            return nil, err
+        }
+        if app.Instances == nil {
+            app.Instances = make([]*InstanceInfo, 0)
+        }
+        apps = append(apps, app)
+    }
+    _, err = decoder.Token()
+    return apps, err
+}
+
+// decodeObject 逐个处理json对象的key(由handle读取对应value)
+func decodeObject(decoder *json.Decoder, handle func(key string) error) error {
+    isNull, err := decodeNullableObject(decoder, handle)
+    if err == nil && isNull {
+        err = errors.New("unexpected token: null, expect: {")
+    }
+    return err
+}
+
+// decodeNullableObject 逐个处理json对象的key(由handle读取对应value), 允许null
+func decodeNullableObject(decoder *json.Decoder, handle func(key string) error) (bool, error) {
+    token, err := decoder.Token()
+    if err != nil {
+        return false, err
+    }
+    if token == nil {
+        return true, nil
+    }
+    if delim, ok := token.(json.Delim); !ok || delim != '{' {
+        return false, errors.New(fmt.Sprintf("unexpected token: %v, expect: {", token))
+    }
+    for decoder.More() {
+        token, err = decoder.Token()
+        if err != nil {
+            return false, err
+        }
+        if err = handle(token.(string)); err != nil {
+            return false, err
+        }
+    }
+    _, err = decoder.Token()
+    return false, err
+}
+
+// skipValue 跳过当前json值
+func skipValue(decoder *json.Decoder) error {
+    depth := 0
+    for {
+        token, err := decoder.Token()
+        if err != nil {
+            return err
+        }
+        if delim, ok := token.(json.Delim); ok {
+            switch delim {
+            case '{', '[':
+                depth++
+            case '}', ']':
+                depth--
+            }
+        }
+        if depth == 0 {
+            return nil
+        }
+    }
+}
@@ -0,0 +1,71 @@
+package meta
+
+import (
+    "github.com/stretchr/testify/assert"
+    "strings"
+    "testing"
+)
+
+func TestParseApplications(t *testing.T) {
+    ast := assert.New(t)
+    applications, err := ParseApplications([]byte(`{"applications": {"versions__delta": "12", "apps__hashcode": "UP_1_", "application": [` + TestAppInfo + `]}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal(int64(12), applications.VersionsDelta)
+    ast.Equal("UP_1_", applications.AppsHashcode)
+    ast.Len(applications.Apps, 1)
+    ast.Equal(applications.AppsHashcode, applications.ComputeHashcode())
+
+    // 兼容数字格式及空值
+    applications, err = ParseApplications([]byte(`{"applications": {"versions__delta": 3, "apps__hashcode": null, "application": []}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal(int64(3), applications.VersionsDelta)
+    ast.Equal("", applications.AppsHashcode)
+    applications, err = ParseApplications([]byte(`{"applications": {"application": []}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal(int64(0), applications.VersionsDelta)
+    _, err = ParseApplications([]byte(`{"applications": {"versions__delta": "x", "application": []}}`))
+    ast.NotNil(err)
+    _, err = ParseApplications([]byte(`{"applications": {"versions__delta": {}, "application": []}}`))
+    ast.NotNil(err)
+}
+
+func TestApplications_ToJson(t *testing.T) {
+    ast := assert.New(t)
+    app, err := ParseAppInfo([]byte(TestAppInfo))
+    ast.Nilf(err, "%v", err)
+    applications := &Applications{VersionsDelta: 7, Apps: []*AppInfo{app}}
+    applications.AppsHashcode = applications.ComputeHashcode()
+    data, err := applications.ToJson()
+    ast.Nilf(err, "%v", err)
+    ast.True(strings.HasPrefix(string(data), `{"applications":{"versions__delta":"7","apps__hashcode":"UP_1_","application":[`))
+    parsed, err := ParseApplications(data)
+    ast.Nilf(err, "%v", err)
+    ast.Equal(applications, parsed)
+
+    data, err = (&Applications{}).ToJson()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(`{"applications":{"versions__delta":"0","apps__hashcode":"","application":[]}}`, string(data))
+}
+
+func TestComputeAppsHashcode(t *testing.T) {
+    ast := assert.New(t)
+    ast.Equal("", ComputeAppsHashcode(nil))
+    ast.Equal("", ComputeAppsHashcode([]*AppInfo{{Name: "EMPTY"}}))
+    apps := []*AppInfo{
+        {Name: "ORDER", Instances: []*InstanceInfo{{Status: StatusUp}, {Status: StatusDown}, {Status: StatusUp}, nil}},
+        {Name: "USER", Instances: []*InstanceInfo{{Status: StatusOutOfService}, {Status: StatusUp}, {Status: ""}, {Status: StatusStarting}}},
+        nil,
+    }
+    // 按状态名称字典序排列
+    ast.Equal("DOWN_1_OUT_OF_SERVICE_1_STARTING_1_UNKNOWN_1_UP_3_", ComputeAppsHashcode(apps))
+    ast.Equal("UP_5000_", ComputeAppsHashcode(mustParseApplications(newTestAppsPayload(100, 50)).Apps))
+}
+
+// mustParseApplications 解析服务列表, 失败时panic
+func mustParseApplications(data []byte) *Applications {
+    applications, err := ParseApplications(data)
+    if err != nil {
+        panic(err)
+    }
+    return applications
+}
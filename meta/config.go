@@ -1,12 +1,11 @@
 package meta
 
 import (
+    "context"
     "encoding/json"
     "errors"
     "fmt"
     "github.com/google/uuid"
-    "net"
-    "os"
     "strings"
 )
 
@@ -44,7 +43,8 @@ var (
 type InstanceConfig struct {
     // 应用名称, 默认: DefaultAppName
     AppName string `json:"app-name"`
-    // 服务实例ID, 默认为生成的uuid
+    // 服务实例ID(支持模板, 见 ExpandInstanceTemplate), 默认: DefaultInstanceIdTemplate(原默认值为UUID), 未指定 NonSecurePort 时仍使用UUID;
+    // 同一进程内多个客户端使用相同应用名称、主机名及端口时需分别指定
     InstanceId string `json:"instance-id"`
     // 实例的主机名, 默认为本机hostname
     Hostname string `json:"hostname"`
@@ -68,9 +68,9 @@ type InstanceConfig struct {
     SecurePort int `json:"secure-port"`
     // 是否启用https通讯端口, 默认: DefaultSecurePortEnabled
     SecurePortEnabled *bool `json:"secure-port-enabled"`
-    // 为此实例定义的虚拟主机名, 默认: AppName
+    // 为此实例定义的虚拟主机名(支持模板, 见 ExpandInstanceTemplate), 默认: AppName
     VirtualHostname string `json:"virtual-hostname"`
-    // 为此实例定义的安全虚拟主机名, 默认: AppName
+    // 为此实例定义的安全虚拟主机名(支持模板, 见 ExpandInstanceTemplate), 默认: AppName
     SecureVirtualHostname string `json:"secure-virtual-hostname"`
     // 实例的状态页面绝对URL路径, 默认为空
     StatusPageUrl string `json:"status-page-url"`
@@ -84,6 +84,12 @@ type InstanceConfig struct {
     HealthCheckUrl string `json:"health-check-url"`
     // 实例的健康检查页面相对URL路径, 默认: DefaultHealthCheckUrlPath
     HealthCheckUrlPath string `json:"health-check-url-path"`
+    // 数据中心信息, 默认: DefaultDataCenterInfo(设置 AmazonInfoProvider 时为AWS数据中心信息)
+    DataCenterInfo *DataCenterInfo `json:"data-center-info"`
+    // AWS数据中心元数据提供者(可选), 未设置 DataCenterInfo 时于 EurekaConfig.Check 中获取AWS数据中心元数据
+    AmazonInfoProvider AmazonInfoProvider `json:"-"`
+    // 主机信息解析(可选), 未设置 Hostname 或 IpAddress 时用于获取主机名及IP地址(含附加服务实例), 默认使用 DefaultHostInfoResolver
+    HostInfoResolver HostInfoResolver `json:"-"`
 }
 
 // ParseInstanceConfig 从json中解析实例配置信息
@@ -199,22 +205,28 @@ func (config *EurekaConfig) Check() error {
         return config.checkedError
     }
     ic, cc := config.InstanceConfig, config.ClientConfig
-    hostInfo, err := GetLocalHostInfo()
-    if err != nil {
-        return err
-    }
     // InstanceConfig解析处理
     nic := &InstanceConfig{}
     if ic == nil {
         ic = &InstanceConfig{}
     }
+    nic.HostInfoResolver = ic.HostInfoResolver
+    // 仅当未指定主机名或IP地址时解析主机信息
+    hostInfo := &HostInfo{Hostname: strings.TrimSpace(ic.Hostname), IpAddress: strings.TrimSpace(ic.IpAddress)}
+    if hostInfo.Hostname == "" || hostInfo.IpAddress == "" {
+        resolved, err := resolveHostInfo(nic.HostInfoResolver)
+        if err != nil {
+            return err
+        }
+        hostInfo = resolved
+    }
     nic.AppName = strings.TrimSpace(ic.AppName)
     if nic.AppName == "" {
         nic.AppName = DefaultAppName
     }
     nic.InstanceId = strings.TrimSpace(ic.InstanceId)
     if nic.InstanceId == "" {
-        nic.InstanceId = uuid.New().String()
+        nic.InstanceId = DefaultInstanceIdTemplate
     }
     nic.Hostname = strings.TrimSpace(ic.Hostname)
     if nic.Hostname == "" {
@@ -287,6 +299,18 @@ func (config *EurekaConfig) Check() error {
     if nic.HealthCheckUrlPath == "" {
         nic.HealthCheckUrlPath = DefaultHealthCheckUrlPath
     }
+    nic.AmazonInfoProvider = ic.AmazonInfoProvider
+    nic.DataCenterInfo = ic.DataCenterInfo.Copy()
+    if nic.DataCenterInfo == nil && nic.AmazonInfoProvider != nil {
+        info, err := nic.AmazonInfoProvider.AmazonInfo(context.Background())
+        if err != nil {
+            return errors.New(fmt.Sprintf("failed to get amazon info: %v", err))
+        }
+        nic.DataCenterInfo = AmazonDataCenterInfo(info)
+    }
+    if nic.DataCenterInfo == nil {
+        nic.DataCenterInfo = DefaultDataCenterInfo()
+    }
     // ClientConfig解析处理
     ncc := &ClientConfig{}
     if cc == nil {
@@ -371,6 +395,27 @@ func (config *EurekaConfig) Check() error {
         }
         ncc.ServiceUrlOfAllZone[zone] = strings.TrimSpace(ncc.ServiceUrlOfAllZone[zone])
     }
+    // 解析服务实例ID及虚拟主机名模板
+    templateContext := &InstanceTemplateContext{
+        Hostname:      nic.Hostname,
+        IpAddress:     nic.IpAddress,
+        AppName:       nic.AppName,
+        NonSecurePort: nic.NonSecurePort,
+        SecurePort:    nic.SecurePort,
+        Region:        ncc.Region,
+        Zone:          ncc.Zone,
+    }
+    for _, field := range []*string{&nic.InstanceId, &nic.VirtualHostname, &nic.SecureVirtualHostname} {
+        value, err := ExpandInstanceTemplate(*field, templateContext)
+        if err != nil {
+            return err
+        }
+        *field = value
+    }
+    // 默认服务实例ID未指定端口时各实例无法区分, 使用UUID
+    if strings.TrimSpace(ic.InstanceId) == "" && ic.NonSecurePort <= 0 {
+        nic.InstanceId = uuid.New().String()
+    }
     config.InstanceConfig = nic
     config.ClientConfig = ncc
     config.checked = true
@@ -400,41 +445,24 @@ type HostInfo struct {
     IpAddress string
 }
 
-// LocalHostInfo 本机信息缓存
+// LocalHostInfo 最近一次解析的本机信息(仅记录, 不作为缓存)
 var LocalHostInfo *HostInfo
 
-// GetLocalHostInfo 获取本机信息
+// GetLocalHostInfo 获取本机信息(每次由 DefaultHostInfoResolver 重新解析, 以便感知主机名及IP地址变化)
 func GetLocalHostInfo() (*HostInfo, error) {
-    if LocalHostInfo == nil {
-        hostname, err := os.Hostname()
-        if err != nil {
-            return nil, errors.New(fmt.Sprintf("failed to get the local hostname, error: %v", err))
-        }
-        ipAddress, err := GetLocalIpv4Address()
-        if err != nil {
-            return nil, errors.New(fmt.Sprintf("failed to get the local ip, error: %v", err))
-        }
-        LocalHostInfo = &HostInfo{
-            hostname,
-            ipAddress,
-        }
+    localHostInfoMutex.Lock()
+    defer localHostInfoMutex.Unlock()
+    hostInfo, err := DefaultHostInfoResolver.Resolve()
+    if err != nil {
+        return nil, err
     }
-    return LocalHostInfo, nil
+    LocalHostInfo = hostInfo
+    return hostInfo, nil
 }
 
 // GetLocalIpv4Address 获取本机IP(ipv4)
 func GetLocalIpv4Address() (string, error) {
-    address, err := net.InterfaceAddrs()
-    if err != nil {
-        return "", err
-    }
-    for _, addr := range address {
-        // 取第一个非lo的网卡IP
-        if in, ok := addr.(*net.IPNet); ok && !in.IP.IsLoopback() && in.IP.To4() != nil {
-            return in.IP.String(), nil
-        }
-    }
-    return "", errors.New("ipv4 address not found")
+    return (&NetworkHostInfoResolver{}).detectIpAddress()
 }
 
 // EurekaServer eureka server 连接信息
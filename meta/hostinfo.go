@@ -0,0 +1,280 @@
+package meta
+
+import (
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "os"
+    "regexp"
+    "strings"
+    "sync"
+)
+
+var (
+    DefaultHostnameEnv  = "EUREKA_INSTANCE_HOSTNAME"
+    DefaultIpAddressEnv = "EUREKA_INSTANCE_IP_ADDRESS"
+)
+
+// IpVersion 主机IP地址版本选择策略
+type IpVersion string
+
+const (
+    // IPv4Only 仅使用ipv4地址(默认)
+    IPv4Only IpVersion = "IPV4_ONLY"
+    // IPv6Only 仅使用ipv6地址
+    IPv6Only IpVersion = "IPV6_ONLY"
+    // PreferIPv4 优先使用ipv4地址, 不存在时使用ipv6地址
+    PreferIPv4 IpVersion = "PREFER_IPV4"
+    // PreferIPv6 优先使用ipv6地址, 不存在时使用ipv4地址
+    PreferIPv6 IpVersion = "PREFER_IPV6"
+)
+
+// HostInfoResolver 主机信息解析
+type HostInfoResolver interface {
+    // Resolve 解析当前主机信息
+    Resolve() (*HostInfo, error)
+}
+
+// HostInterface 网卡信息
+type HostInterface struct {
+    Name  string
+    Flags net.Flags
+    Addrs []net.IP
+}
+
+// NetworkHostInfoResolver 根据环境变量、主机名文件及网卡信息解析主机信息, 优先级: 环境变量 > 主机名文件 > 网卡及系统主机名
+type NetworkHostInfoResolver struct {
+    // 主机名环境变量名称, 默认: DefaultHostnameEnv
+    HostnameEnv string
+    // IP地址环境变量名称, 默认: DefaultIpAddressEnv
+    IpAddressEnv string
+    // 主机名文件(如: /etc/hostname, 读取首行), 默认不读取
+    HostnameFile string
+    // 优先网段(CIDR, 如: 10.0.0.0/8), 按顺序匹配, 均不匹配时使用其余地址
+    PreferredNetworks []string
+    // 仅使用名称匹配的网卡(正则, 全匹配, 如: eth.*), 默认使用所有网卡
+    IncludeInterfaces []string
+    // 排除名称匹配的网卡(正则, 全匹配, 如: docker.*, veth.*, tun.*)
+    ExcludeInterfaces []string
+    // IP地址版本选择策略, 默认: IPv4Only
+    IpVersion IpVersion
+    // 获取网卡信息(测试时可替换), 默认读取本机网卡
+    Interfaces func() ([]HostInterface, error)
+}
+
+// Resolve 解析当前主机信息
+func (resolver *NetworkHostInfoResolver) Resolve() (*HostInfo, error) {
+    hostname, err := resolver.resolveHostname()
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to get the local hostname, error: %v", err))
+    }
+    ipAddress, err := resolver.resolveIpAddress()
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("failed to get the local ip, error: %v", err))
+    }
+    return &HostInfo{Hostname: hostname, IpAddress: ipAddress}, nil
+}
+
+// resolveHostname 解析主机名
+func (resolver *NetworkHostInfoResolver) resolveHostname() (string, error) {
+    if hostname := lookupEnv(resolver.HostnameEnv, DefaultHostnameEnv); hostname != "" {
+        return hostname, nil
+    }
+    if resolver.HostnameFile != "" {
+        data, err := ioutil.ReadFile(resolver.HostnameFile)
+        if err != nil {
+            return "", err
+        }
+        if hostname := strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0]); hostname != "" {
+            return hostname, nil
+        }
+    }
+    return os.Hostname()
+}
+
+// resolveIpAddress 解析IP地址
+func (resolver *NetworkHostInfoResolver) resolveIpAddress() (string, error) {
+    if ipAddress := lookupEnv(resolver.IpAddressEnv, DefaultIpAddressEnv); ipAddress != "" {
+        if net.ParseIP(ipAddress) == nil {
+            return "", errors.New(fmt.Sprintf("invalid ip address: %s", ipAddress))
+        }
+        return ipAddress, nil
+    }
+    return resolver.detectIpAddress()
+}
+
+// detectIpAddress 根据网卡信息选择IP地址
+func (resolver *NetworkHostInfoResolver) detectIpAddress() (string, error) {
+    networks := make([]*net.IPNet, 0, len(resolver.PreferredNetworks))
+    for _, cidr := range resolver.PreferredNetworks {
+        _, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+        if err != nil {
+            return "", err
+        }
+        networks = append(networks, network)
+    }
+    includes, err := compileInterfacePatterns(resolver.IncludeInterfaces)
+    if err != nil {
+        return "", err
+    }
+    excludes, err := compileInterfacePatterns(resolver.ExcludeInterfaces)
+    if err != nil {
+        return "", err
+    }
+    interfaces := resolver.Interfaces
+    if interfaces == nil {
+        interfaces = localInterfaces
+    }
+    hostInterfaces, err := interfaces()
+    if err != nil {
+        return "", err
+    }
+    candidates := make([]net.IP, 0)
+    for _, hostInterface := range hostInterfaces {
+        if hostInterface.Flags&net.FlagUp == 0 || hostInterface.Flags&net.FlagLoopback != 0 {
+            continue
+        }
+        if len(includes) > 0 && !matchInterface(includes, hostInterface.Name) {
+            continue
+        }
+        if matchInterface(excludes, hostInterface.Name) {
+            continue
+        }
+        for _, ip := range hostInterface.Addrs {
+            // 排除回环地址及ipv6链路本地地址(需指定zone才能访问)
+            if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+                continue
+            }
+            candidates = append(candidates, ip)
+        }
+    }
+    ip := selectIp(candidates, networks, resolver.IpVersion)
+    if ip == nil {
+        return "", errors.New(fmt.Sprintf("ip address not found, ip version: %s", resolver.ipVersion()))
+    }
+    return ip.String(), nil
+}
+
+// ipVersion IP地址版本选择策略
+func (resolver *NetworkHostInfoResolver) ipVersion() IpVersion {
+    if resolver.IpVersion == "" {
+        return IPv4Only
+    }
+    return resolver.IpVersion
+}
+
+// selectIp 按IP版本及优先网段选择IP地址
+func selectIp(candidates []net.IP, networks []*net.IPNet, version IpVersion) net.IP {
+    var families [][]net.IP
+    ipv4s, ipv6s := make([]net.IP, 0), make([]net.IP, 0)
+    for _, ip := range candidates {
+        if ip.To4() != nil {
+            ipv4s = append(ipv4s, ip)
+        } else {
+            ipv6s = append(ipv6s, ip)
+        }
+    }
+    switch version {
+    case IPv6Only:
+        families = [][]net.IP{ipv6s}
+    case PreferIPv4:
+        families = [][]net.IP{ipv4s, ipv6s}
+    case PreferIPv6:
+        families = [][]net.IP{ipv6s, ipv4s}
+    default:
+        families = [][]net.IP{ipv4s}
+    }
+    for _, ips := range families {
+        for _, network := range networks {
+            for _, ip := range ips {
+                if network.Contains(ip) {
+                    return ip
+                }
+            }
+        }
+        if len(ips) > 0 {
+            return ips[0]
+        }
+    }
+    return nil
+}
+
+// compileInterfacePatterns 编译网卡名称匹配规则
+func compileInterfacePatterns(patterns []string) ([]*regexp.Regexp, error) {
+    compiled := make([]*regexp.Regexp, 0, len(patterns))
+    for _, pattern := range patterns {
+        re, err := regexp.Compile("^(?:" + strings.TrimSpace(pattern) + ")$")
+        if err != nil {
+            return nil, err
+        }
+        compiled = append(compiled, re)
+    }
+    return compiled, nil
+}
+
+// matchInterface 网卡名称是否匹配任一规则
+func matchInterface(patterns []*regexp.Regexp, name string) bool {
+    for _, pattern := range patterns {
+        if pattern.MatchString(name) {
+            return true
+        }
+    }
+    return false
+}
+
+// lookupEnv 读取环境变量(name为空时使用defaultName)
+func lookupEnv(name, defaultName string) string {
+    if name == "" {
+        name = defaultName
+    }
+    return strings.TrimSpace(os.Getenv(name))
+}
+
+// localInterfaces 读取本机网卡信息
+func localInterfaces() ([]HostInterface, error) {
+    interfaces, err := net.Interfaces()
+    if err != nil {
+        return nil, err
+    }
+    hostInterfaces := make([]HostInterface, 0, len(interfaces))
+    for _, ni := range interfaces {
+        addrs, err := ni.Addrs()
+        if err != nil {
+            return nil, err
+        }
+        hostInterface := HostInterface{Name: ni.Name, Flags: ni.Flags, Addrs: make([]net.IP, 0, len(addrs))}
+        for _, addr := range addrs {
+            if in, ok := addr.(*net.IPNet); ok {
+                hostInterface.Addrs = append(hostInterface.Addrs, in.IP)
+            }
+        }
+        hostInterfaces = append(hostInterfaces, hostInterface)
+    }
+    return hostInterfaces, nil
+}
+
+// DefaultHostInfoResolver 默认主机信息解析, 用于 GetLocalHostInfo
+var DefaultHostInfoResolver HostInfoResolver = &NetworkHostInfoResolver{}
+
+// localHostInfoMutex 默认主机信息解析锁
+var localHostInfoMutex sync.Mutex
+
+// SetDefaultHostInfoResolver 替换默认主机信息解析
+func SetDefaultHostInfoResolver(resolver HostInfoResolver) {
+    localHostInfoMutex.Lock()
+    defer localHostInfoMutex.Unlock()
+    if resolver == nil {
+        resolver = &NetworkHostInfoResolver{}
+    }
+    DefaultHostInfoResolver = resolver
+    LocalHostInfo = nil
+}
+
+// resolveHostInfo 使用指定主机信息解析获取主机信息(为nil时使用 DefaultHostInfoResolver)
+func resolveHostInfo(resolver HostInfoResolver) (*HostInfo, error) {
+    if resolver == nil {
+        return GetLocalHostInfo()
+    }
+    return resolver.Resolve()
+}
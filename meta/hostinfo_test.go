@@ -0,0 +1,171 @@
+package meta
+
+import (
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "net"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// testInterfaces 测试使用的网卡信息
+func testInterfaces() ([]HostInterface, error) {
+    up := net.FlagUp | net.FlagBroadcast
+    return []HostInterface{
+        {Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}},
+        {Name: "docker0", Flags: up, Addrs: []net.IP{net.ParseIP("172.17.0.1")}},
+        {Name: "tun0", Flags: up, Addrs: []net.IP{net.ParseIP("10.8.0.2")}},
+        {Name: "eth1", Flags: 0, Addrs: []net.IP{net.ParseIP("192.168.9.9")}},
+        {Name: "eth0", Flags: up, Addrs: []net.IP{net.ParseIP("fe80::1"), net.ParseIP("192.168.1.10"), net.ParseIP("2001:db8::10")}},
+        {Name: "eth2", Flags: up, Addrs: []net.IP{net.ParseIP("10.0.0.5")}},
+    }, nil
+}
+
+// resolveTestIp 使用测试网卡信息解析IP地址
+func resolveTestIp(ast *assert.Assertions, resolver *NetworkHostInfoResolver) string {
+    resolver.Interfaces = testInterfaces
+    hostInfo, err := resolver.Resolve()
+    ast.Nilf(err, "%v", err)
+    return hostInfo.IpAddress
+}
+
+func TestNetworkHostInfoResolver(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv(DefaultHostnameEnv, "")
+    t.Setenv(DefaultIpAddressEnv, "")
+
+    // 默认取首个启用的非回环ipv4地址
+    ast.Equal("172.17.0.1", resolveTestIp(ast, &NetworkHostInfoResolver{}))
+    // 排除网卡
+    ast.Equal("192.168.1.10", resolveTestIp(ast, &NetworkHostInfoResolver{ExcludeInterfaces: []string{"docker.*", "tun\\d+"}}))
+    // 仅使用指定网卡
+    ast.Equal("10.0.0.5", resolveTestIp(ast, &NetworkHostInfoResolver{IncludeInterfaces: []string{"eth[12]"}}))
+    // 优先网段, 按顺序匹配
+    ast.Equal("10.8.0.2", resolveTestIp(ast, &NetworkHostInfoResolver{PreferredNetworks: []string{"10.0.0.0/8", "192.168.0.0/16"}}))
+    ast.Equal("10.0.0.5", resolveTestIp(ast, &NetworkHostInfoResolver{PreferredNetworks: []string{"10.0.0.0/24"}}))
+    ast.Equal("172.17.0.1", resolveTestIp(ast, &NetworkHostInfoResolver{PreferredNetworks: []string{"192.0.2.0/24"}}))
+    // ipv6(排除链路本地地址)
+    ast.Equal("2001:db8::10", resolveTestIp(ast, &NetworkHostInfoResolver{IpVersion: IPv6Only}))
+    ast.Equal("2001:db8::10", resolveTestIp(ast, &NetworkHostInfoResolver{IpVersion: PreferIPv6}))
+    ast.Equal("172.17.0.1", resolveTestIp(ast, &NetworkHostInfoResolver{IpVersion: PreferIPv4}))
+    // ipv6-only主机
+    ipv6Only := func() ([]HostInterface, error) {
+        return []HostInterface{{Name: "eth0", Flags: net.FlagUp, Addrs: []net.IP{net.ParseIP("fe80::1"), net.ParseIP("fd00::5")}}}, nil
+    }
+    hostInfo, err := (&NetworkHostInfoResolver{IpVersion: PreferIPv4, Interfaces: ipv6Only}).Resolve()
+    ast.Nilf(err, "%v", err)
+    ast.Equal("fd00::5", hostInfo.IpAddress)
+    _, err = (&NetworkHostInfoResolver{Interfaces: ipv6Only}).Resolve()
+    ast.NotNil(err)
+
+    // 非法配置及获取网卡信息失败
+    _, err = (&NetworkHostInfoResolver{PreferredNetworks: []string{"10.0.0.0"}, Interfaces: testInterfaces}).Resolve()
+    ast.NotNil(err)
+    _, err = (&NetworkHostInfoResolver{ExcludeInterfaces: []string{"eth("}, Interfaces: testInterfaces}).Resolve()
+    ast.NotNil(err)
+    _, err = (&NetworkHostInfoResolver{Interfaces: func() ([]HostInterface, error) { return nil, errors.New("denied") }}).Resolve()
+    ast.NotNil(err)
+}
+
+func TestNetworkHostInfoResolver_Overrides(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv(DefaultHostnameEnv, "")
+    t.Setenv(DefaultIpAddressEnv, "")
+    systemHostname, err := os.Hostname()
+    ast.Nilf(err, "%v", err)
+    hostInfo, err := (&NetworkHostInfoResolver{Interfaces: testInterfaces}).Resolve()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(systemHostname, hostInfo.Hostname)
+
+    // 主机名文件(读取首行)
+    file := filepath.Join(t.TempDir(), "hostname")
+    ast.Nil(os.WriteFile(file, []byte("  pod-1.example.com \nignored\n"), 0644))
+    resolver := &NetworkHostInfoResolver{HostnameFile: file, Interfaces: testInterfaces}
+    hostInfo, err = resolver.Resolve()
+    ast.Nilf(err, "%v", err)
+    ast.Equal("pod-1.example.com", hostInfo.Hostname)
+    _, err = (&NetworkHostInfoResolver{HostnameFile: file + ".missing", Interfaces: testInterfaces}).Resolve()
+    ast.NotNil(err)
+
+    // 环境变量优先
+    t.Setenv(DefaultHostnameEnv, "env-host")
+    t.Setenv(DefaultIpAddressEnv, "2001:db8::20")
+    hostInfo, err = resolver.Resolve()
+    ast.Nilf(err, "%v", err)
+    ast.Equal(&HostInfo{Hostname: "env-host", IpAddress: "2001:db8::20"}, hostInfo)
+    t.Setenv("POD_IP", "10.1.2.3")
+    hostInfo, err = (&NetworkHostInfoResolver{IpAddressEnv: "POD_IP", Interfaces: testInterfaces}).Resolve()
+    ast.Nilf(err, "%v", err)
+    ast.Equal("10.1.2.3", hostInfo.IpAddress)
+    t.Setenv(DefaultIpAddressEnv, "not-an-ip")
+    _, err = resolver.Resolve()
+    ast.NotNil(err)
+}
+
+// staticHostInfoResolver 固定主机信息解析
+type staticHostInfoResolver struct {
+    hostInfo *HostInfo
+    calls    int
+}
+
+// Resolve 返回固定主机信息
+func (resolver *staticHostInfoResolver) Resolve() (*HostInfo, error) {
+    resolver.calls++
+    if resolver.hostInfo == nil {
+        return nil, errors.New("host info not found")
+    }
+    return resolver.hostInfo, nil
+}
+
+func TestEurekaConfig_HostInfoResolver(t *testing.T) {
+    ast := assert.New(t)
+    resolver := &staticHostInfoResolver{hostInfo: &HostInfo{Hostname: "pod-1", IpAddress: "2001:db8::1"}}
+    config := &EurekaConfig{InstanceConfig: &InstanceConfig{HostInfoResolver: resolver, NonSecurePort: 8080}}
+    ast.Nil(config.Check())
+    ast.Equal("pod-1", config.Hostname)
+    ast.Equal("2001:db8::1", config.IpAddress)
+    ast.Equal(1, resolver.calls)
+
+    // 已指定主机名及IP地址时不解析
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{HostInfoResolver: resolver, Hostname: "a", IpAddress: "10.0.0.1"}}
+    ast.Nil(config.Check())
+    ast.Equal(1, resolver.calls)
+
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{HostInfoResolver: &staticHostInfoResolver{}}}
+    ast.NotNil(config.Check())
+}
+
+func TestInstanceInfo_CheckWithHostInfoResolver(t *testing.T) {
+    ast := assert.New(t)
+    resolver := &staticHostInfoResolver{hostInfo: &HostInfo{Hostname: "pod-1", IpAddress: "2001:db8::1"}}
+    SetDefaultHostInfoResolver(resolver)
+    defer SetDefaultHostInfoResolver(nil)
+
+    instance := &InstanceInfo{Port: &PortWrapper{Enabled: StrTrue, Port: 8080}}
+    ast.Nil(instance.Check())
+    ast.Equal("pod-1", instance.HostName)
+    ast.Equal("2001:db8::1", instance.IpAddr)
+    // ipv6地址拼接url时添加方括号
+    ast.Equal("http://[2001:db8::1]:8080/actuator/health", instance.HealthCheckUrl)
+    instance.HostName = instance.IpAddr
+    httpUrl, err := instance.HttpServiceUrl()
+    ast.Nilf(err, "%v", err)
+    ast.Equal("http://[2001:db8::1]:8080", httpUrl)
+
+    // 不缓存解析结果, 主机信息变化时重新解析
+    resolver.hostInfo = &HostInfo{Hostname: "pod-2", IpAddress: "10.0.0.2"}
+    instance = &InstanceInfo{}
+    ast.Nil(instance.Check())
+    ast.Equal("pod-2", instance.HostName)
+    ast.Equal(2, resolver.calls)
+
+    // 使用指定主机信息解析
+    custom := &staticHostInfoResolver{hostInfo: &HostInfo{Hostname: "pod-3", IpAddress: "10.0.0.3"}}
+    instance = &InstanceInfo{}
+    ast.Nil(instance.CheckWithResolver(custom))
+    ast.Equal("pod-3", instance.HostName)
+    ast.Equal("10.0.0.3", instance.IpAddr)
+    ast.Equal(1, custom.calls)
+    ast.Equal(2, resolver.calls)
+}
@@ -2,16 +2,22 @@ package meta
 
 import (
     "encoding/json"
+    "encoding/xml"
     "errors"
     "fmt"
     "github.com/google/uuid"
+    "net"
+    "regexp"
     "strconv"
 )
 
 // DataCenterInfo 数据中心
 type DataCenterInfo struct {
-    Class string `json:"@class"`
-    Name  string `json:"name"`
+    XMLName xml.Name `json:"-" xml:"dataCenterInfo"`
+    Class   string   `json:"@class" xml:"class,attr"`
+    Name    string   `json:"name" xml:"name"`
+    // AWS数据中心元数据, 仅当 Name 为 AmazonDataCenterName 时有效
+    Metadata *AmazonInfo `json:"metadata,omitempty" xml:"metadata,omitempty"`
 }
 
 // Copy 复制副本
@@ -20,8 +26,9 @@ func (dc *DataCenterInfo) Copy() *DataCenterInfo {
         return nil
     }
     return &DataCenterInfo{
-        Class: dc.Class,
-        Name:  dc.Name,
+        Class:    dc.Class,
+        Name:     dc.Name,
+        Metadata: dc.Metadata.Copy(),
     }
 }
 
@@ -137,6 +144,17 @@ const (
     Deleted  ActionType = "DELETED"
 )
 
+// metadataKeyPattern 元数据key格式(需同时作为eureka server XML编码的元素名称)
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// ValidateMetadataKey 检查元数据key: 以字母或下划线开头, 仅包含字母、数字、下划线、点及中划线
+func ValidateMetadataKey(key string) error {
+    if !metadataKeyPattern.MatchString(key) {
+        return errors.New(fmt.Sprintf("metadata key is invalid: '%s'", key))
+    }
+    return nil
+}
+
 // InstanceInfo 服务实例信息
 type InstanceInfo struct {
     InstanceId                    string            `json:"instanceId"`
@@ -172,7 +190,7 @@ func (instance *InstanceInfo) ToJson() ([]byte, error) {
 // HttpServiceUrl 获取服务实例的http调用地址
 func (instance *InstanceInfo) HttpServiceUrl() (string, error) {
     if instance.Port != nil && instance.Port.IsEnabled() {
-        return HttpProtocol + instance.HostName + ":" + strconv.Itoa(instance.Port.Port), nil
+        return HttpProtocol + net.JoinHostPort(instance.HostName, strconv.Itoa(instance.Port.Port)), nil
     }
     return "", errors.New("the non-secure port of the service instance is not enabled")
 }
@@ -180,7 +198,7 @@ func (instance *InstanceInfo) HttpServiceUrl() (string, error) {
 // HttpsServiceUrl 获取服务实例的https调用地址
 func (instance *InstanceInfo) HttpsServiceUrl() (string, error) {
     if instance.SecurePort != nil && instance.SecurePort.IsEnabled() {
-        return HttpsProtocol + instance.HostName + ":" + strconv.Itoa(instance.SecurePort.Port), nil
+        return HttpsProtocol + net.JoinHostPort(instance.HostName, strconv.Itoa(instance.SecurePort.Port)), nil
     }
     return "", errors.New("the secure port of the service instance is not enabled")
 }
@@ -240,24 +258,31 @@ func ParseInstanceInfo(data []byte) (instance *InstanceInfo, err error) {
     return instance, instance.Check()
 }
 
-// Check 检查属性
+// Check 检查属性(未设置主机名或IP地址时使用 DefaultHostInfoResolver 解析)
 func (instance *InstanceInfo) Check() error {
-    hostInfo, err := GetLocalHostInfo()
-    if err != nil {
-        return err
+    return instance.CheckWithResolver(nil)
+}
+
+// CheckWithResolver 检查属性, 未设置主机名或IP地址时使用指定主机信息解析(为nil时使用 DefaultHostInfoResolver)
+func (instance *InstanceInfo) CheckWithResolver(resolver HostInfoResolver) error {
+    if instance.HostName == "" || instance.IpAddr == "" {
+        hostInfo, err := resolveHostInfo(resolver)
+        if err != nil {
+            return err
+        }
+        if instance.HostName == "" {
+            instance.HostName = hostInfo.Hostname
+        }
+        if instance.IpAddr == "" {
+            instance.IpAddr = hostInfo.IpAddress
+        }
     }
     if instance.InstanceId == "" {
         instance.InstanceId = uuid.New().String()
     }
-    if instance.HostName == "" {
-        instance.HostName = hostInfo.Hostname
-    }
     if instance.AppName == "" {
         instance.AppName = DefaultAppName
     }
-    if instance.IpAddr == "" {
-        instance.IpAddr = hostInfo.IpAddress
-    }
     if instance.Status == "" {
         instance.Status = StatusStarting
     }
@@ -288,13 +313,13 @@ func (instance *InstanceInfo) Check() error {
         protocol, ipAddr, port = HttpsProtocol, instance.IpAddr, instance.SecurePort.Port
     }
     if instance.StatusPageUrl == "" {
-        instance.StatusPageUrl = fmt.Sprintf("%s%s:%d%s", protocol, ipAddr, port, DefaultStatusPageUrlPath)
+        instance.StatusPageUrl = protocol + net.JoinHostPort(ipAddr, strconv.Itoa(port)) + DefaultStatusPageUrlPath
     }
     if instance.HomePageUrl == "" {
-        instance.HomePageUrl = fmt.Sprintf("%s%s:%d%s", protocol, ipAddr, port, DefaultHomePageUrlPath)
+        instance.HomePageUrl = protocol + net.JoinHostPort(ipAddr, strconv.Itoa(port)) + DefaultHomePageUrlPath
     }
     if instance.HealthCheckUrl == "" {
-        instance.HealthCheckUrl = fmt.Sprintf("%s%s:%d%s", protocol, ipAddr, port, DefaultHealthCheckUrlPath)
+        instance.HealthCheckUrl = protocol + net.JoinHostPort(ipAddr, strconv.Itoa(port)) + DefaultHealthCheckUrlPath
     }
     if instance.VipAddress == "" {
         instance.VipAddress = instance.AppName
@@ -0,0 +1,82 @@
+package meta
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+)
+
+// AsgStatus AWS ASG(Auto Scaling Group)状态
+type AsgStatus string
+
+const (
+    AsgEnabled  AsgStatus = "ENABLED"
+    AsgDisabled AsgStatus = "DISABLED"
+)
+
+// ReplicationAction eureka server节点间批量复制操作类型
+type ReplicationAction string
+
+const (
+    ReplicationHeartbeat            ReplicationAction = "Heartbeat"
+    ReplicationRegister             ReplicationAction = "Register"
+    ReplicationCancel               ReplicationAction = "Cancel"
+    ReplicationStatusUpdate         ReplicationAction = "StatusUpdate"
+    ReplicationDeleteStatusOverride ReplicationAction = "DeleteStatusOverride"
+)
+
+// ReplicationInstance 批量复制的单个服务实例操作
+type ReplicationInstance struct {
+    AppName            string            `json:"appName"`
+    Id                 string            `json:"id"`
+    LastDirtyTimestamp int64             `json:"lastDirtyTimestamp,omitempty"`
+    OverriddenStatus   InstanceStatus    `json:"overriddenStatus,omitempty"`
+    Status             InstanceStatus    `json:"status,omitempty"`
+    InstanceInfo       *InstanceInfo     `json:"instanceInfo,omitempty"`
+    Action             ReplicationAction `json:"action"`
+}
+
+// ReplicationInstanceResponse 批量复制的单个服务实例操作结果
+type ReplicationInstanceResponse struct {
+    StatusCode     int           `json:"statusCode"`
+    ResponseEntity *InstanceInfo `json:"responseEntity,omitempty"`
+}
+
+// ServerStatus eureka server状态信息
+type ServerStatus struct {
+    // 通用统计(如: environment, num-of-cpus, server-uptime)
+    GeneralStats map[string]string `json:"generalStats"`
+    // 应用统计(如: registered-replicas, available-replicas, unavailable-replicas)
+    ApplicationStats map[string]string `json:"applicationStats"`
+    // eureka server自身服务实例信息
+    InstanceInfo *InstanceInfo `json:"instanceInfo"`
+}
+
+// ParseServerStatus 从json中解析eureka server状态信息
+func ParseServerStatus(data []byte) (status *ServerStatus, err error) {
+    defer func() {
+        if rc := recover(); rc != nil {
+            status = nil
+            err = errors.New(fmt.Sprintf("ParseServerStatus, recover error: %v", rc))
+        }
+    }()
+    wrapper := make(map[string]json.RawMessage)
+    if err = json.Unmarshal(data, &wrapper); err != nil {
+        return nil, err
+    }
+    // 兼容带根节点的响应: {"statusInfo": {...}}
+    if raw, ok := wrapper["statusInfo"]; ok {
+        data = raw
+    }
+    status = &ServerStatus{}
+    if err = json.Unmarshal(data, status); err != nil {
+        return nil, err
+    }
+    if status.GeneralStats == nil {
+        status.GeneralStats = make(map[string]string)
+    }
+    if status.ApplicationStats == nil {
+        status.ApplicationStats = make(map[string]string)
+    }
+    return status, nil
+}
@@ -0,0 +1,24 @@
+package meta
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func TestParseServerStatus(t *testing.T) {
+    ast := assert.New(t)
+    status, err := ParseServerStatus([]byte(`{"generalStats":{"environment":"test"},"applicationStats":{"available-replicas":"http://peer/eureka/"},"instanceInfo":{"app":"EUREKA","instanceId":"eureka-1","status":"UP"}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal("test", status.GeneralStats["environment"])
+    ast.Equal("http://peer/eureka/", status.ApplicationStats["available-replicas"])
+    ast.Equal("eureka-1", status.InstanceInfo.InstanceId)
+
+    status, err = ParseServerStatus([]byte(`{"statusInfo":{"generalStats":{"environment":"prod"}}}`))
+    ast.Nilf(err, "%v", err)
+    ast.Equal("prod", status.GeneralStats["environment"])
+    ast.NotNil(status.ApplicationStats)
+    ast.Nil(status.InstanceInfo)
+
+    _, err = ParseServerStatus([]byte(`[]`))
+    ast.NotNil(err)
+}
@@ -0,0 +1,97 @@
+package meta
+
+import (
+    "errors"
+    "fmt"
+    "github.com/google/uuid"
+    "os"
+    "strconv"
+    "strings"
+)
+
+var (
+    // DefaultInstanceIdTemplate 默认服务实例ID模板(与Spring Cloud一致)
+    DefaultInstanceIdTemplate = "${hostname}:${appName}:${port}"
+    // DefaultRandomLength ${random} 默认长度
+    DefaultRandomLength = 8
+)
+
+
+// InstanceTemplateContext 服务实例模板变量
+type InstanceTemplateContext struct {
+    Hostname      string
+    IpAddress     string
+    AppName       string
+    NonSecurePort int
+    SecurePort    int
+    Region        string
+    Zone          string
+}
+
+// ExpandInstanceTemplate 解析服务实例模板, 支持的占位符:
+// ${hostname}, ${ip}, ${appName}, ${port}, ${securePort}, ${region}, ${zone},
+// ${env.NAME}(环境变量, 可指定默认值: ${env.NAME:default}), ${random}(随机十六进制字符串, 可指定长度: ${random:4})
+func ExpandInstanceTemplate(template string, context *InstanceTemplateContext) (string, error) {
+    if context == nil {
+        context = &InstanceTemplateContext{}
+    }
+    builder := strings.Builder{}
+    for {
+        start := strings.Index(template, "${")
+        if start < 0 {
+            builder.WriteString(template)
+            return builder.String(), nil
+        }
+        end := strings.Index(template[start:], "}")
+        if end < 0 {
+            return "", errors.New(fmt.Sprintf("unclosed placeholder in template: %s", template))
+        }
+        value, err := context.resolve(template[start+2 : start+end])
+        if err != nil {
+            return "", err
+        }
+        builder.WriteString(template[:start])
+        builder.WriteString(value)
+        template = template[start+end+1:]
+    }
+}
+
+// resolve 解析单个占位符
+func (context *InstanceTemplateContext) resolve(placeholder string) (string, error) {
+    name, arg, hasArg := strings.Cut(strings.TrimSpace(placeholder), ":")
+    switch {
+    case name == "hostname" && !hasArg:
+        return context.Hostname, nil
+    case name == "ip" && !hasArg:
+        return context.IpAddress, nil
+    case name == "appName" && !hasArg:
+        return context.AppName, nil
+    case name == "port" && !hasArg:
+        return strconv.Itoa(context.NonSecurePort), nil
+    case name == "securePort" && !hasArg:
+        return strconv.Itoa(context.SecurePort), nil
+    case name == "region" && !hasArg:
+        return context.Region, nil
+    case name == "zone" && !hasArg:
+        return context.Zone, nil
+    case strings.HasPrefix(name, "env.") && len(name) > len("env."):
+        if value, ok := os.LookupEnv(strings.TrimPrefix(name, "env.")); ok {
+            return value, nil
+        }
+        if hasArg {
+            return arg, nil
+        }
+        return "", errors.New(fmt.Sprintf("environment variable not found: %s", strings.TrimPrefix(name, "env.")))
+    case name == "random":
+        length := DefaultRandomLength
+        if hasArg {
+            n, err := strconv.Atoi(arg)
+            if err != nil || n <= 0 || n > 32 {
+                return "", errors.New(fmt.Sprintf("invalid random length: %s, expect: 1-32", arg))
+            }
+            length = n
+        }
+        return strings.ReplaceAll(uuid.New().String(), "-", "")[:length], nil
+    }
+    return "", errors.New(fmt.Sprintf("unknown placeholder: ${%s}", placeholder))
+}
@@ -0,0 +1,98 @@
+package meta
+
+import (
+    "github.com/google/uuid"
+    "github.com/stretchr/testify/assert"
+    "regexp"
+    "testing"
+)
+
+func TestExpandInstanceTemplate(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv("POD_NAME", "order-7d9f")
+    context := &InstanceTemplateContext{
+        Hostname:      "host-1",
+        IpAddress:     "10.0.0.1",
+        AppName:       "order",
+        NonSecurePort: 8080,
+        SecurePort:    8443,
+        Region:        "us-east-1",
+        Zone:          "zone1",
+    }
+    for template, expect := range map[string]string{
+        "":                                  "",
+        "static-id":                         "static-id",
+        DefaultInstanceIdTemplate:           "host-1:order:8080",
+        "${ip}:${securePort}":               "10.0.0.1:8443",
+        "${region}/${zone}/${ appName }":    "us-east-1/zone1/order",
+        "${env.POD_NAME}.${appName}":        "order-7d9f.order",
+        "${env.MISSING_TEST_ENV:local}-id":  "local-id",
+        "${env.MISSING_TEST_ENV:}${ip}":     "10.0.0.1",
+        "$host-{${hostname}}":               "$host-{host-1}",
+    } {
+        value, err := ExpandInstanceTemplate(template, context)
+        ast.Nilf(err, "%v", err)
+        ast.Equal(expect, value, template)
+    }
+
+    // 随机后缀
+    value, err := ExpandInstanceTemplate("${appName}-${random}", context)
+    ast.Nilf(err, "%v", err)
+    ast.Regexp(regexp.MustCompile("^order-[0-9a-f]{8}$"), value)
+    another, err := ExpandInstanceTemplate("${appName}-${random}", context)
+    ast.Nilf(err, "%v", err)
+    ast.NotEqual(value, another)
+    value, err = ExpandInstanceTemplate("${random:4}", context)
+    ast.Nilf(err, "%v", err)
+    ast.Len(value, 4)
+
+    for _, template := range []string{"${unknown}", "${hostname", "${env.MISSING_TEST_ENV}", "${env.}", "${random:0}", "${random:33}", "${random:x}", "${port:1}"} {
+        _, err = ExpandInstanceTemplate(template, context)
+        ast.NotNil(err, template)
+    }
+    value, err = ExpandInstanceTemplate("${appName}:${port}", nil)
+    ast.Nilf(err, "%v", err)
+    ast.Equal(":0", value)
+}
+
+func TestEurekaConfig_InstanceTemplate(t *testing.T) {
+    ast := assert.New(t)
+    t.Setenv("POD_NAME", "order-7d9f")
+
+    // 默认服务实例ID与Spring Cloud一致
+    config := &EurekaConfig{InstanceConfig: &InstanceConfig{AppName: "order", Hostname: "host-1", IpAddress: "10.0.0.1", NonSecurePort: 8080}}
+    ast.Nil(config.Check())
+    ast.Equal("host-1:order:8080", config.InstanceId)
+    ast.Equal("order", config.VirtualHostname)
+
+    // 相同配置的默认服务实例ID保持不变
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{AppName: "order", Hostname: "host-1", IpAddress: "10.0.0.1", NonSecurePort: 8080}}
+    ast.Nil(config.Check())
+    ast.Equal("host-1:order:8080", config.InstanceId)
+
+    // 未指定端口时使用UUID
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{AppName: "order", Hostname: "host-2", IpAddress: "10.0.0.2"}}
+    ast.Nil(config.Check())
+    _, err := uuid.Parse(config.InstanceId)
+    ast.Nilf(err, "%v", err)
+
+    config = &EurekaConfig{
+        InstanceConfig: &InstanceConfig{
+            AppName:               "order",
+            Hostname:              "host-1",
+            IpAddress:             "10.0.0.1",
+            InstanceId:            "${env.POD_NAME}:${ip}:${port}",
+            SecurePort:            8443,
+            VirtualHostname:       "${appName}.${zone}",
+            SecureVirtualHostname: "${appName}-secure.${region}",
+        },
+        ClientConfig: &ClientConfig{Region: "us-east-1", Zone: "zone1"},
+    }
+    ast.Nil(config.Check())
+    ast.Equal("order-7d9f:10.0.0.1:80", config.InstanceId)
+    ast.Equal("order.zone1", config.VirtualHostname)
+    ast.Equal("order-secure.us-east-1", config.SecureVirtualHostname)
+
+    config = &EurekaConfig{InstanceConfig: &InstanceConfig{Hostname: "h", IpAddress: "10.0.0.1", InstanceId: "${unknown}"}}
+    ast.NotNil(config.Check())
+}